@@ -0,0 +1,406 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/tors/wt-go-sdk/wt"
+)
+
+func (a *app) send(ctx context.Context, args []string) error {
+	fs := a.flagSet("send", "Usage: wt send [-m message] [-archive format] files...\n")
+	message := fs.String("m", "", "transfer `message`")
+	archive := fs.String("archive", "", "send directories as a single zip or tar.gz `format` archive")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	files, err := expandFiles(fs.Args(), *archive)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.client(ctx, a.progress())
+	if err != nil {
+		return err
+	}
+
+	for _, w := range client.Transfers.Validate(files...).Warnings() {
+		fmt.Fprintf(a.stderr, "wt: %v\n", w)
+	}
+
+	var msg *string
+	if *message != "" {
+		msg = message
+	}
+
+	transfer, err := client.Transfers.Create(ctx, msg, files...)
+	if err != nil {
+		return err
+	}
+	return a.printTransfer(transfer)
+}
+
+func (a *app) transferShow(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		fmt.Fprint(a.stderr, "Usage: wt transfer show <id>\n")
+		return errUsage
+	}
+
+	client, err := a.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	transfer, err := client.Transfers.Find(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.printTransfer(transfer)
+}
+
+func (a *app) transferDownload(ctx context.Context, args []string) error {
+	fs := a.flagSet("transfer download", "Usage: wt transfer download [-o dir] <id>\n")
+	dir := fs.String("o", ".", "output `directory`")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	client, err := a.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	transfer, err := client.Transfers.Find(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if err := client.Transfers.DownloadAll(ctx, transfer, *dir); err != nil {
+		return err
+	}
+	return a.printTransfer(transfer)
+}
+
+func (a *app) boardCreate(ctx context.Context, args []string) error {
+	fs := a.flagSet("board create", "Usage: wt board create [-d description] <name>\n")
+	desc := fs.String("d", "", "board `description`")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	client, err := a.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	var d *string
+	if *desc != "" {
+		d = desc
+	}
+
+	board, err := client.Boards.Create(ctx, fs.Arg(0), d)
+	if err != nil {
+		return err
+	}
+	return a.printBoard(board)
+}
+
+func (a *app) boardAddLinks(ctx context.Context, args []string) error {
+	fs := a.flagSet("board add-links", "Usage: wt board add-links [-titles] [-new] <id> urls...\n")
+	titles := fs.Bool("titles", false, "fetch the titles of the pages")
+	onlyNew := fs.Bool("new", false, "skip links already on the board")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return errUsage
+	}
+
+	var links []*wt.Link
+	for _, u := range fs.Args()[1:] {
+		link, err := wt.NewLink(u, nil)
+		if err != nil {
+			return err
+		}
+		links = append(links, link)
+	}
+
+	var opt wt.Option
+	if *titles {
+		opt = wt.WithTitleResolver(wt.HTMLTitleResolver)
+	}
+
+	client, err := a.client(ctx, opt)
+	if err != nil {
+		return err
+	}
+
+	board, err := client.Boards.Find(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var items []*wt.Item
+	if *onlyNew {
+		items, err = client.Boards.AddNewLinks(ctx, board, links...)
+	} else {
+		items, err = client.Boards.AddLinks(ctx, board, links...)
+	}
+	if err != nil {
+		return err
+	}
+	return a.printItems(items)
+}
+
+func (a *app) boardAddFiles(ctx context.Context, args []string) error {
+	if len(args) < 2 {
+		fmt.Fprint(a.stderr, "Usage: wt board add-files <id> files...\n")
+		return errUsage
+	}
+
+	files, err := expandFiles(args[1:], "")
+	if err != nil {
+		return err
+	}
+
+	client, err := a.client(ctx, a.progress())
+	if err != nil {
+		return err
+	}
+
+	board, err := client.Boards.Find(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	items, err := client.Boards.AddFiles(ctx, board, files...)
+	if err != nil {
+		return err
+	}
+	return a.printItems(items)
+}
+
+func (a *app) boardUpdate(ctx context.Context, args []string) error {
+	fs := a.flagSet("board update", "Usage: wt board update [-name name] [-d description] <id>\n")
+	name := fs.String("name", "", "new board `name`")
+	desc := fs.String("d", "", "new board `description`")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 || (*name == "" && *desc == "") {
+		fs.Usage()
+		return errUsage
+	}
+
+	var n, d *string
+	if *name != "" {
+		n = name
+	}
+	if *desc != "" {
+		d = desc
+	}
+
+	client, err := a.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	board, err := client.Boards.Update(ctx, &wt.Board{ID: wt.String(fs.Arg(0))}, n, d)
+	if err != nil {
+		return err
+	}
+	return a.printBoard(board)
+}
+
+func (a *app) boardRemove(ctx context.Context, args []string) error {
+	if len(args) < 2 {
+		fmt.Fprint(a.stderr, "Usage: wt board remove <id> item-ids...\n")
+		return errUsage
+	}
+
+	client, err := a.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	board, err := client.Boards.Find(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := client.Boards.RemoveItems(ctx, board, args[1:]...); err != nil {
+		return err
+	}
+	return a.printBoard(board)
+}
+
+func (a *app) boardDelete(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		fmt.Fprint(a.stderr, "Usage: wt board delete <id>\n")
+		return errUsage
+	}
+
+	client, err := a.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	return client.Boards.Delete(ctx, &wt.Board{ID: wt.String(args[0])})
+}
+
+func (a *app) boardSync(ctx context.Context, args []string) error {
+	fs := a.flagSet("board sync", "Usage: wt board sync [-n] [-links file] <id> dir\n")
+	dryRun := fs.Bool("n", false, "print the changes without making them")
+	links := fs.String("links", "", "`file` of the directory listing links to add")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errUsage
+	}
+
+	client, err := a.client(ctx, a.progress())
+	if err != nil {
+		return err
+	}
+
+	result, err := client.Boards.Sync(ctx, &wt.Board{ID: wt.String(fs.Arg(0))}, fs.Arg(1), &wt.SyncOptions{
+		LinksFile: *links,
+		DryRun:    *dryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	if a.json {
+		return a.printJSON(result)
+	}
+
+	w := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	for _, action := range result.Actions {
+		switch action.Kind {
+		case wt.SyncAddLink:
+			fmt.Fprintf(w, "%v\t%v\n", action.Kind, action.URL)
+		case wt.SyncSkip:
+			fmt.Fprintf(w, "%v\t%v\n", action.Kind, action.Name)
+		default:
+			fmt.Fprintf(w, "%v\t%v\t%v\n", action.Kind, action.Name, formatBytes(action.Size))
+		}
+	}
+	fmt.Fprintf(w, "%d change(s), %d file(s) unchanged\n", len(result.Actions), result.Unchanged)
+	return w.Flush()
+}
+
+func (a *app) boardShow(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		fmt.Fprint(a.stderr, "Usage: wt board show <id>\n")
+		return errUsage
+	}
+
+	client, err := a.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	board, err := client.Boards.Find(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.printBoard(board)
+}
+
+// progress returns the option showing progress bars, if they are enabled.
+func (a *app) progress() wt.Option {
+	if a.quiet || !a.tty {
+		return nil
+	}
+	return wt.WithProgress(newProgressBar(a.stderr).update)
+}
+
+func (a *app) printJSON(v interface{}) error {
+	enc := json.NewEncoder(a.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (a *app) printTransfer(t *wt.Transfer) error {
+	if a.json {
+		return a.printJSON(t)
+	}
+
+	w := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%v\n", t.GetID())
+	fmt.Fprintf(w, "State:\t%v\n", deref(t.State))
+	if t.Message != nil {
+		fmt.Fprintf(w, "Message:\t%v\n", *t.Message)
+	}
+	fmt.Fprintf(w, "URL:\t%v\n", t.GetURL())
+	fmt.Fprintf(w, "Expires:\t%v\n", deref(t.ExpiresAt))
+	fmt.Fprintf(w, "Files:\t\n")
+	for _, f := range t.Files {
+		fmt.Fprintf(w, "  %v\t%v\t%v\n", f.GetID(), formatBytes(f.GetSize()), f.GetName())
+	}
+	return w.Flush()
+}
+
+func (a *app) printBoard(b *wt.Board) error {
+	if a.json {
+		return a.printJSON(b)
+	}
+
+	w := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%v\n", b.GetID())
+	fmt.Fprintf(w, "Name:\t%v\n", b.GetName())
+	if b.Desc != nil {
+		fmt.Fprintf(w, "Description:\t%v\n", b.GetDesc())
+	}
+	fmt.Fprintf(w, "State:\t%v\n", b.GetState())
+	fmt.Fprintf(w, "URL:\t%v\n", b.GetURL())
+	fmt.Fprintf(w, "Items:\t\n")
+	writeItems(w, b.Items)
+	return w.Flush()
+}
+
+func (a *app) printItems(items []*wt.Item) error {
+	if a.json {
+		return a.printJSON(items)
+	}
+
+	w := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	writeItems(w, items)
+	return w.Flush()
+}
+
+func writeItems(w *tabwriter.Writer, items []*wt.Item) {
+	for _, i := range items {
+		if i.IsLink() {
+			fmt.Fprintf(w, "  %v\tlink\t%v\n", i.GetID(), i.GetURL())
+		} else {
+			fmt.Fprintf(w, "  %v\t%v\t%v\n", i.GetID(), formatBytes(i.GetSize()), i.GetName())
+		}
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
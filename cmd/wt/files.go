@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tors/wt-go-sdk/wt"
+)
+
+// archiveFormats maps the values of the -archive flag to archive formats.
+var archiveFormats = map[string]wt.ArchiveFormat{
+	"zip":    wt.ArchiveZip,
+	"tar.gz": wt.ArchiveTarGz,
+}
+
+// expandFiles returns the files named by the arguments of a command. Glob
+// patterns are expanded and directories are walked recursively. Hidden files
+// found in directories are skipped. If archive names a format, directories
+// are sent as a single archive each.
+func expandFiles(args []string, archive string) ([]wt.Uploadable, error) {
+	format, ok := archiveFormats[archive]
+	if archive != "" && !ok {
+		return nil, fmt.Errorf("unknown archive format %q, want zip or tar.gz", archive)
+	}
+
+	var up []wt.Uploadable
+
+	for _, arg := range args {
+		paths := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, err
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("%v: no matching files", arg)
+			}
+			paths = matches
+		}
+
+		for _, p := range paths {
+			info, err := os.Stat(p)
+			if err != nil {
+				return nil, err
+			}
+
+			switch {
+			case !info.IsDir():
+				f, err := wt.NewLocalFile(p)
+				if err != nil {
+					return nil, err
+				}
+				up = append(up, f)
+			case archive != "":
+				a, err := wt.NewArchive(p, format, nil)
+				if err != nil {
+					return nil, err
+				}
+				up = append(up, a)
+			default:
+				files, err := wt.NewDirectory(p, nil)
+				if err != nil {
+					return nil, err
+				}
+				up = append(up, files...)
+			}
+		}
+	}
+
+	return up, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.txt"), "a")
+	writeFile(t, filepath.Join(dir, "b.jpg"), "b")
+	writeFile(t, filepath.Join(dir, ".hidden"), "h")
+	writeFile(t, filepath.Join(dir, "sub", "c.txt"), "c")
+	writeFile(t, filepath.Join(dir, ".git", "d.txt"), "d")
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{dir}, []string{"a.txt", "b.jpg", "c.txt"}},
+		{[]string{filepath.Join(dir, "*.txt")}, []string{"a.txt"}},
+		{[]string{filepath.Join(dir, "b.jpg"), filepath.Join(dir, "sub")}, []string{"b.jpg", "c.txt"}},
+	}
+
+	for _, tt := range tests {
+		up, err := expandFiles(tt.args, "")
+		if err != nil {
+			t.Fatalf("expandFiles returned error: %v", err)
+		}
+		var got []string
+		for _, u := range up {
+			name, _ := u.Stat()
+			got = append(got, name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("expandFiles(%q) returned %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestExpandFiles_errors(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, arg := range []string{filepath.Join(dir, "*.txt"), filepath.Join(dir, "missing")} {
+		if _, err := expandFiles([]string{arg}, ""); err == nil {
+			t.Errorf("expandFiles(%q) returned no error", arg)
+		}
+	}
+}
+
+func TestExpandFiles_archive(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.txt"), "a")
+	writeFile(t, filepath.Join(dir, "pics", "b.jpg"), "b")
+	writeFile(t, filepath.Join(dir, "pics", "sub", "c.jpg"), "c")
+
+	up, err := expandFiles([]string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "pics")}, "tar.gz")
+	if err != nil {
+		t.Fatalf("expandFiles returned error: %v", err)
+	}
+
+	var got []string
+	for _, u := range up {
+		name, _ := u.Stat()
+		got = append(got, name)
+	}
+	if want := []string{"a.txt", "pics.tar.gz"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expandFiles returned %v, want %v", got, want)
+	}
+
+	if _, err := expandFiles([]string{dir}, "rar"); err == nil {
+		t.Error("expandFiles with an unknown archive format returned no error")
+	}
+}
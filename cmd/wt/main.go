@@ -0,0 +1,260 @@
+// Command wt sends and fetches WeTransfer transfers and boards.
+//
+// Usage:
+//
+//	wt [-json] [-q] [-config file] <command> [arguments]
+//
+// The commands are:
+//
+//	send [-m message] [-archive format] files...
+//	                                         create a transfer
+//	transfer show <id>                       show a transfer
+//	transfer download [-o dir] <id>          download the files of a transfer
+//	board create [-d description] <name>     create a board
+//	board add-links [-titles] [-new] <id> urls...
+//	                                         add links to a board
+//	board add-files <id> files...            add files to a board
+//	board show <id>                          show a board
+//	board update [-name name] [-d desc] <id> rename or re-describe a board
+//	board remove <id> item-ids...            remove items from a board
+//	board delete <id>                        delete a board
+//	board sync [-n] [-links file] <id> dir   mirror a directory to a board
+//
+// Files may be directories, which are sent recursively, or glob patterns.
+// With -archive zip or -archive tar.gz, each directory is sent as a single
+// archive keeping its structure.
+//
+// The API key is read from the WETRANSFER_API_TOKEN environment variable, or
+// from the config file, which holds KEY=value lines:
+//
+//	WETRANSFER_API_TOKEN=<your-api-key>
+//
+// The config file defaults to wt/config in the user config directory.
+// WETRANSFER_API_URL overrides the base URL of the API the same way.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/tors/wt-go-sdk/wt"
+)
+
+const (
+	apiKeyVar  = "WETRANSFER_API_TOKEN"
+	baseURLVar = "WETRANSFER_API_URL"
+)
+
+const usage = `Usage: wt [-json] [-q] [-v] [-debug] [-config file] <command> [arguments]
+
+Commands:
+  send [-m message] [-archive format] files...
+                                           create a transfer
+  transfer show <id>                       show a transfer
+  transfer download [-o dir] <id>          download the files of a transfer
+  board create [-d description] <name>     create a board
+  board add-links [-titles] [-new] <id> urls...
+                                           add links to a board
+  board add-files <id> files...            add files to a board
+  board show <id>                          show a board
+  board update [-name name] [-d desc] <id> rename or re-describe a board
+  board remove <id> item-ids...            remove items from a board
+  board delete <id>                        delete a board
+  board sync [-n] [-links file] <id> dir   mirror a directory to a board
+
+Flags:
+`
+
+// errUsage reports a command line the usage was printed for.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	a := &app{
+		getenv: os.Getenv,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+		tty:    isTerminal(os.Stderr),
+	}
+
+	err := a.run(ctx, os.Args[1:])
+	if err == errUsage {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "wt: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// app holds the environment and the global flags of a run of the command.
+type app struct {
+	getenv func(string) string
+	stdout io.Writer
+	stderr io.Writer
+	tty    bool // whether stderr is a terminal, for progress bars
+
+	json    bool
+	quiet   bool
+	verbose bool
+	debug   bool
+	config  string
+}
+
+// run parses the command line and runs the command.
+func (a *app) run(ctx context.Context, args []string) error {
+	fs := a.flagSet("wt", usage)
+	fs.BoolVar(&a.json, "json", false, "print JSON output")
+	fs.BoolVar(&a.quiet, "q", false, "do not show progress")
+	fs.BoolVar(&a.verbose, "v", false, "log requests to stderr")
+	fs.BoolVar(&a.debug, "debug", false, "log requests to stderr, with their bodies")
+	fs.StringVar(&a.config, "config", defaultConfig(), "config `file`")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	args = fs.Args()
+	if len(args) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	switch cmd, args := args[0], args[1:]; cmd {
+	case "send":
+		return a.send(ctx, args)
+	case "transfer":
+		return a.subcommand(ctx, "transfer", args, map[string]func(context.Context, []string) error{
+			"show":     a.transferShow,
+			"download": a.transferDownload,
+		})
+	case "board":
+		return a.subcommand(ctx, "board", args, map[string]func(context.Context, []string) error{
+			"create":    a.boardCreate,
+			"add-links": a.boardAddLinks,
+			"add-files": a.boardAddFiles,
+			"show":      a.boardShow,
+			"update":    a.boardUpdate,
+			"remove":    a.boardRemove,
+			"delete":    a.boardDelete,
+			"sync":      a.boardSync,
+		})
+	default:
+		fmt.Fprintf(a.stderr, "wt: unknown command %q\n", cmd)
+		fs.Usage()
+		return errUsage
+	}
+}
+
+func (a *app) subcommand(ctx context.Context, name string, args []string, cmds map[string]func(context.Context, []string) error) error {
+	if len(args) > 0 {
+		if cmd, ok := cmds[args[0]]; ok {
+			return cmd(ctx, args[1:])
+		}
+		fmt.Fprintf(a.stderr, "wt: unknown command %q\n", name+" "+args[0])
+	}
+	fmt.Fprint(a.stderr, usage[:strings.Index(usage, "\nFlags:")])
+	return errUsage
+}
+
+// flagSet returns a flag set printing its usage to stderr.
+func (a *app) flagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprint(a.stderr, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// client returns an authorized client configured from the environment and
+// the config file.
+func (a *app) client(ctx context.Context, opts ...wt.Option) (*wt.Client, error) {
+	conf, err := readConfig(a.config)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := func(key string) string {
+		if v := a.getenv(key); v != "" {
+			return v
+		}
+		return conf[key]
+	}
+
+	apiKey := lookup(apiKeyVar)
+	if apiKey == "" {
+		return nil, fmt.Errorf("no API key: set %v in the environment or in %v", apiKeyVar, a.config)
+	}
+
+	opts = append([]wt.Option{wt.WithUserAgent("wt-cli")}, opts...)
+	if u := lookup(baseURLVar); u != "" {
+		opts = append(opts, wt.WithBaseURL(u))
+	}
+
+	if a.verbose || a.debug {
+		logger := slog.New(slog.NewTextHandler(a.stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		opts = append(opts, wt.WithLogger(logger), wt.WithDebug(a.debug))
+	}
+
+	return wt.NewAuthorizedClient(ctx, apiKey, opts...)
+}
+
+// defaultConfig returns the path of the config file in the user config
+// directory.
+func defaultConfig() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "wt", "config")
+}
+
+// readConfig reads the KEY=value lines of a config file. Blank lines and lines
+// starting with # are ignored. A missing file is an empty config.
+func readConfig(path string) (map[string]string, error) {
+	conf := make(map[string]string)
+	if path == "" {
+		return conf, nil
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return conf, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("%v:%d: expected KEY=value", path, n)
+		}
+		conf[strings.TrimSpace(line[:i])] = strings.TrimSpace(line[i+1:])
+	}
+	return conf, s.Err()
+}
+
+// isTerminal reports whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
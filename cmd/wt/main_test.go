@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tors/wt-go-sdk/wt"
+	"github.com/tors/wt-go-sdk/wttest"
+)
+
+// setup returns an app talking to a fake API, and a function running it with
+// arguments and returning its output.
+func setup(t *testing.T) (*app, *wttest.Server, func(args ...string) (string, error)) {
+	t.Helper()
+
+	server := wttest.NewServer()
+	t.Cleanup(server.Close)
+
+	env := map[string]string{
+		apiKeyVar:  "key",
+		baseURLVar: server.URL(),
+	}
+
+	var stdout, stderr bytes.Buffer
+	a := &app{
+		getenv: func(k string) string { return env[k] },
+		stdout: &stdout,
+		stderr: &stderr,
+	}
+
+	run := func(args ...string) (string, error) {
+		stdout.Reset()
+		stderr.Reset()
+		args = append([]string{"-config", ""}, args...)
+		err := a.run(context.Background(), args)
+		return stdout.String(), err
+	}
+
+	return a, server, run
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSend(t *testing.T) {
+	_, _, run := setup(t)
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.txt"), "a")
+	writeFile(t, filepath.Join(dir, "sub", "b.txt"), "bb")
+
+	out, err := run("-json", "send", "-m", "hi", dir)
+	if err != nil {
+		t.Fatalf("send returned error: %v", err)
+	}
+
+	transfer := new(wt.Transfer)
+	if err := json.Unmarshal([]byte(out), transfer); err != nil {
+		t.Fatalf("send printed invalid JSON: %v\n%v", err, out)
+	}
+
+	if got, want := *transfer.Message, "hi"; got != want {
+		t.Errorf("send message is %v, want %v", got, want)
+	}
+
+	var names []string
+	for _, f := range transfer.Files {
+		names = append(names, f.GetName())
+	}
+	if got, want := strings.Join(names, ","), "a.txt,b.txt"; got != want {
+		t.Errorf("send files are %v, want %v", got, want)
+	}
+}
+
+func TestTransferShowAndDownload(t *testing.T) {
+	_, _, run := setup(t)
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.txt"), "hello")
+
+	out, err := run("-json", "send", filepath.Join(dir, "*.txt"))
+	if err != nil {
+		t.Fatalf("send returned error: %v", err)
+	}
+	transfer := new(wt.Transfer)
+	json.Unmarshal([]byte(out), transfer)
+
+	out, err = run("transfer", "show", transfer.GetID())
+	if err != nil {
+		t.Fatalf("transfer show returned error: %v", err)
+	}
+	for _, want := range []string{transfer.GetID(), transfer.GetURL(), "a.txt", "5 B"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("transfer show printed %q, want it to contain %q", out, want)
+		}
+	}
+
+	dst := t.TempDir()
+	if _, err := run("transfer", "download", "-o", dst, transfer.GetID()); err != nil {
+		t.Fatalf("transfer download returned error: %v", err)
+	}
+	b, _ := ioutil.ReadFile(filepath.Join(dst, "a.txt"))
+	if got, want := string(b), "hello"; got != want {
+		t.Errorf("transfer download wrote %q, want %q", got, want)
+	}
+}
+
+func TestBoard(t *testing.T) {
+	_, _, run := setup(t)
+
+	out, err := run("-json", "board", "create", "-d", "desc", "Pony")
+	if err != nil {
+		t.Fatalf("board create returned error: %v", err)
+	}
+	board := new(wt.Board)
+	json.Unmarshal([]byte(out), board)
+
+	if _, err := run("board", "add-links", board.GetID(), "https://example.com"); err != nil {
+		t.Fatalf("board add-links returned error: %v", err)
+	}
+
+	file := filepath.Join(t.TempDir(), "pony.txt")
+	writeFile(t, file, "yeehaaa")
+	if _, err := run("board", "add-files", board.GetID(), file); err != nil {
+		t.Fatalf("board add-files returned error: %v", err)
+	}
+
+	out, err = run("board", "show", board.GetID())
+	if err != nil {
+		t.Fatalf("board show returned error: %v", err)
+	}
+	for _, want := range []string{"Pony", "desc", "https://example.com", "pony.txt"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("board show printed %q, want it to contain %q", out, want)
+		}
+	}
+}
+
+func TestBoardLifecycle(t *testing.T) {
+	_, _, run := setup(t)
+
+	out, _ := run("-json", "board", "create", "Pony")
+	board := new(wt.Board)
+	json.Unmarshal([]byte(out), board)
+
+	out, _ = run("-json", "board", "add-links", board.GetID(), "https://example.com", "https://example.org")
+	var items []*wt.Item
+	json.Unmarshal([]byte(out), &items)
+
+	out, _ = run("-json", "board", "add-links", "-new", board.GetID(), "https://EXAMPLE.com", "https://example.net")
+	var added []*wt.Item
+	json.Unmarshal([]byte(out), &added)
+	if len(added) != 1 || added[0].GetURL() != "https://example.net/" {
+		t.Errorf("board add-links -new added %v, want only https://example.net/", added)
+	}
+
+	out, err := run("board", "remove", board.GetID(), items[0].GetID())
+	if err != nil {
+		t.Fatalf("board remove returned error: %v", err)
+	}
+	if strings.Contains(out, "example.com") || !strings.Contains(out, "example.org") {
+		t.Errorf("board remove printed %q, want only the remaining link", out)
+	}
+
+	out, err = run("board", "update", "-name", "Horse", "-d", "neigh", board.GetID())
+	if err != nil {
+		t.Fatalf("board update returned error: %v", err)
+	}
+	if !strings.Contains(out, "Horse") || !strings.Contains(out, "neigh") {
+		t.Errorf("board update printed %q, want the new name and description", out)
+	}
+
+	if _, err := run("board", "delete", board.GetID()); err != nil {
+		t.Fatalf("board delete returned error: %v", err)
+	}
+	if _, err := run("board", "show", board.GetID()); err == nil {
+		t.Error("board show of a deleted board returned no error")
+	}
+}
+
+func TestBoardSync(t *testing.T) {
+	_, _, run := setup(t)
+
+	out, _ := run("-json", "board", "create", "Mirror")
+	board := new(wt.Board)
+	json.Unmarshal([]byte(out), board)
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.txt"), "a")
+	writeFile(t, filepath.Join(dir, "links"), "https://example.com Example\n")
+
+	out, err := run("board", "sync", "-n", "-links", "links", board.GetID(), dir)
+	if err != nil {
+		t.Fatalf("board sync -n returned error: %v", err)
+	}
+	if !strings.Contains(out, "2 change(s)") {
+		t.Errorf("board sync -n printed %q, want 2 changes", out)
+	}
+
+	if _, err := run("board", "sync", "-links", "links", board.GetID(), dir); err != nil {
+		t.Fatalf("board sync returned error: %v", err)
+	}
+
+	out, _ = run("board", "sync", "-links", "links", board.GetID(), dir)
+	if !strings.Contains(out, "0 change(s), 1 file(s) unchanged") {
+		t.Errorf("second board sync printed %q, want no changes", out)
+	}
+}
+
+func TestConfigFile(t *testing.T) {
+	a, server, _ := setup(t)
+	a.getenv = func(string) string { return "" }
+
+	a.config = filepath.Join(t.TempDir(), "config")
+	writeFile(t, a.config, "# wt\n"+apiKeyVar+" = key\n"+baseURLVar+"="+server.URL()+"\n")
+
+	if _, err := a.client(context.Background()); err != nil {
+		t.Errorf("client returned error: %v", err)
+	}
+}
+
+func TestNoAPIKey(t *testing.T) {
+	a, _, run := setup(t)
+	a.getenv = func(string) string { return "" }
+
+	_, err := run("board", "show", "id")
+	if err == nil || !strings.Contains(err.Error(), apiKeyVar) {
+		t.Errorf("board show returned %v, want an error about %v", err, apiKeyVar)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	_, _, run := setup(t)
+
+	for _, args := range [][]string{
+		{},
+		{"nope"},
+		{"board"},
+		{"board", "nope"},
+		{"send"},
+		{"transfer", "show"},
+	} {
+		if _, err := run(args...); err != errUsage {
+			t.Errorf("run(%q) returned %v, want %v", args, err, errUsage)
+		}
+	}
+}
+
+func TestVerbose(t *testing.T) {
+	a, _, run := setup(t)
+
+	if _, err := run("-debug", "board", "create", "pets"); err != nil {
+		t.Fatalf("board create returned error: %v", err)
+	}
+
+	logs := a.stderr.(*bytes.Buffer).String()
+	for _, want := range []string{"api request", "path=/v2/boards", "status=201", "X-Api-Key:[REDACTED]", "Bearer REDACTED"} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("board create logged %q, want it to contain %q", logs, want)
+		}
+	}
+	if strings.Contains(logs, "key]") {
+		t.Errorf("board create logged the API key: %v", logs)
+	}
+}
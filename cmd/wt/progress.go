@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tors/wt-go-sdk/wt"
+)
+
+const barWidth = 30
+
+// progressBar renders the progress of an upload on a single terminal line.
+type progressBar struct {
+	w        io.Writer
+	rendered bool // whether the line holds a bar
+}
+
+func newProgressBar(w io.Writer) *progressBar {
+	return &progressBar{w: w}
+}
+
+// update is a wt.ProgressFunc.
+func (b *progressBar) update(p wt.Progress) {
+	switch p.Phase {
+	case wt.PhaseUpload:
+		b.render(p.Bytes, p.TotalSize)
+	case wt.PhaseDone:
+		if b.rendered {
+			fmt.Fprintln(b.w)
+		}
+	}
+}
+
+func (b *progressBar) render(done, total int64) {
+	ratio := 1.0
+	if total > 0 {
+		ratio = float64(done) / float64(total)
+	}
+	n := int(ratio * barWidth)
+	b.rendered = true
+
+	fmt.Fprintf(b.w, "\r[%v%v] %3.0f%% %v / %v",
+		strings.Repeat("=", n), strings.Repeat(" ", barWidth-n),
+		ratio*100, formatBytes(done), formatBytes(total))
+}
+
+// formatBytes returns a human readable size.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
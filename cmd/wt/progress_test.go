@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tors/wt-go-sdk/wt"
+)
+
+func TestProgressBar(t *testing.T) {
+	var buf bytes.Buffer
+	b := newProgressBar(&buf)
+
+	b.update(wt.Progress{Phase: wt.PhaseCreate})
+	b.update(wt.Progress{Phase: wt.PhaseUpload, Bytes: 512, TotalSize: 2048})
+	b.update(wt.Progress{Phase: wt.PhaseDone})
+
+	out := buf.String()
+	for _, want := range []string{" 25%", "512 B / 2.0 KiB", "\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("progress bar printed %q, want it to contain %q", out, want)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+	}
+	for _, tt := range tests {
+		if got := formatBytes(tt.n); got != tt.want {
+			t.Errorf("formatBytes(%v) returned %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
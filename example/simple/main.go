@@ -15,7 +15,7 @@ func main() {
 
 	ctx := context.Background()
 
-	client, err := wt.NewAuthorizedClient(ctx, apiKey, nil)
+	client, err := wt.NewAuthorizedClient(ctx, apiKey)
 	checkErr(err)
 
 	message := "My first pony!"
@@ -1 +1,3 @@
 module github.com/tors/wt-go-sdk
+
+go 1.21
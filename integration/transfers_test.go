@@ -3,7 +3,10 @@
 package integration
 
 import (
+	"bytes"
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/tors/wt-go-sdk/wt"
@@ -15,7 +18,16 @@ func TestTransfersService(t *testing.T) {
 	message := "My first pony!"
 
 	pony := wt.NewBuffer("pony.txt", []byte("yeehaaa"))
-	japan, _ := wt.NewLocalFile("../example/files/Japan-01🇯🇵.jpg")
+
+	// A name with non-ASCII characters, and more than one chunk of content.
+	path := filepath.Join(t.TempDir(), "Japan-01🇯🇵.jpg")
+	if err := os.WriteFile(path, bytes.Repeat([]byte("japan"), 2<<20), 0644); err != nil {
+		t.Fatal(err)
+	}
+	japan, err := wt.NewLocalFile(path)
+	if err != nil {
+		t.Fatalf("NewLocalFile returned an error %v", err)
+	}
 
 	transfer, err := client.Transfers.Create(ctx, &message, pony, japan)
 	if err != nil {
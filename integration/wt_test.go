@@ -11,6 +11,7 @@ import (
 	"os"
 
 	"github.com/tors/wt-go-sdk/wt"
+	"github.com/tors/wt-go-sdk/wttest"
 )
 
 var (
@@ -19,12 +20,28 @@ var (
 )
 
 func init() {
+	if os.Getenv("WT_OFFLINE") != "" {
+		initOffline()
+		return
+	}
+
 	apiKey, err := readToken(".env")
 	if err != nil {
 		log.Fatal(err)
 	}
 	logf(`Using key "%v"`, apiKey)
-	client, err = wt.NewAuthorizedClient(context.Background(), apiKey, nil)
+	client, err = wt.NewAuthorizedClient(context.Background(), apiKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// initOffline runs the tests against a fake WeTransfer API.
+func initOffline() {
+	var err error
+	server := wttest.NewServer()
+	logf("Using fake API at %v", server.URL())
+	client, err = server.NewClient(context.Background())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,177 @@
+package wt
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// ArchiveFormat is the format of an Archive.
+type ArchiveFormat int
+
+// Supported archive formats.
+const (
+	ArchiveZip   ArchiveFormat = iota // .zip
+	ArchiveTarGz                      // .tar.gz
+)
+
+func (f ArchiveFormat) ext() string {
+	if f == ArchiveTarGz {
+		return ".tar.gz"
+	}
+	return ".zip"
+}
+
+// Archive implements the Uploadable interface. It packs the files of a
+// directory into a single zip or tar.gz file, so the structure of the
+// directory survives the transfer. The archive is built while it is
+// uploaded, without being stored.
+//
+// Its size is computed by building it once when the archive is created. The
+// upload fails if the files change in the meantime.
+type Archive struct {
+	name    string
+	size    int64
+	dir     string // name of the top-level directory in the archive
+	format  ArchiveFormat
+	entries []*dirEntry
+}
+
+// NewArchive returns an Archive of the files of the directory at root
+// selected by opts. It is named after the directory, with the extension of
+// the format.
+func NewArchive(root string, format ArchiveFormat, opts *DirectoryOptions) (*Archive, error) {
+	if format != ArchiveZip && format != ArchiveTarGz {
+		return nil, fmt.Errorf("unknown archive format %v", format)
+	}
+
+	entries, err := walkDirectory(root, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Base(abs)
+
+	a := &Archive{
+		name:    sanitizeString(dir + format.ext()),
+		dir:     dir,
+		format:  format,
+		entries: entries,
+	}
+
+	var cw countWriter
+	if err := a.write(&cw); err != nil {
+		return nil, err
+	}
+	a.size = int64(cw)
+
+	return a, nil
+}
+
+// Stat returns the name and the size of the archive.
+func (a *Archive) Stat() (string, int64) {
+	return a.name, a.size
+}
+
+// Open returns a reader of the archive, built as it is read.
+func (a *Archive) Open() (io.ReadCloser, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(a.write(pw))
+	}()
+
+	sr := &sizedReader{r: pr, name: a.name, size: a.size, left: a.size}
+	return &multiReadCloser{Reader: sr, Closer: pr}, nil
+}
+
+// write writes the archive to w.
+func (a *Archive) write(w io.Writer) error {
+	if a.format == ArchiveTarGz {
+		return a.writeTarGz(w)
+	}
+	return a.writeZip(w)
+}
+
+func (a *Archive) writeZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	for _, e := range a.entries {
+		h, err := zip.FileInfoHeader(e.info)
+		if err != nil {
+			return err
+		}
+		h.Name = path.Join(a.dir, e.rel)
+		h.Method = zip.Deflate
+
+		fw, err := zw.CreateHeader(h)
+		if err != nil {
+			return err
+		}
+		if err := copyFile(fw, e); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func (a *Archive) writeTarGz(w io.Writer) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	for _, e := range a.entries {
+		h, err := tar.FileInfoHeader(e.info, "")
+		if err != nil {
+			return err
+		}
+		h.Name = path.Join(a.dir, e.rel)
+
+		if err := tw.WriteHeader(h); err != nil {
+			return err
+		}
+		if err := copyFile(tw, e); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// copyFile copies the content of a file to w, failing if its size changed
+// since it was found.
+func copyFile(w io.Writer, e *dirEntry) error {
+	f, err := os.Open(e.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	n, err := io.Copy(w, io.LimitReader(f, e.info.Size()+1))
+	if err != nil {
+		return err
+	}
+	if n != e.info.Size() {
+		return fmt.Errorf("%v changed size from %d to %d bytes", e.path, e.info.Size(), n)
+	}
+	return nil
+}
+
+// countWriter counts the bytes written to it.
+type countWriter int64
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	*c += countWriter(len(p))
+	return len(p), nil
+}
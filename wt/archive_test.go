@@ -0,0 +1,149 @@
+package wt
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// readArchive returns the content of an archive by file name.
+func readArchive(t *testing.T, a *Archive) map[string]string {
+	t.Helper()
+
+	rc, err := a.Open()
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer rc.Close()
+
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	if _, size := a.Stat(); int64(len(data)) != size {
+		t.Errorf("Open read %v bytes, want %v", len(data), size)
+	}
+
+	files := make(map[string]string)
+
+	switch a.format {
+	case ArchiveZip:
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("zip.NewReader returned error: %v", err)
+		}
+		for _, f := range zr.File {
+			r, _ := f.Open()
+			b, _ := ioutil.ReadAll(r)
+			r.Close()
+			files[f.Name] = string(b)
+		}
+	case ArchiveTarGz:
+		gr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("gzip.NewReader returned error: %v", err)
+		}
+		tr := tar.NewReader(gr)
+		for {
+			h, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Next returned error: %v", err)
+			}
+			b, _ := ioutil.ReadAll(tr)
+			files[h.Name] = string(b)
+		}
+	}
+
+	return files
+}
+
+func TestNewArchive(t *testing.T) {
+	dir := filepath.Join(setupTestDir(t), "docs")
+
+	want := map[string]string{
+		"docs/c.txt":     "ccc",
+		"docs/d.pdf":     "dddd",
+		"docs/tmp/e.txt": "eeeee",
+	}
+
+	for _, tt := range []struct {
+		format ArchiveFormat
+		name   string
+	}{
+		{ArchiveZip, "docs.zip"},
+		{ArchiveTarGz, "docs.tar.gz"},
+	} {
+		a, err := NewArchive(dir, tt.format, nil)
+		if err != nil {
+			t.Fatalf("NewArchive returned error: %v", err)
+		}
+
+		if name, _ := a.Stat(); name != tt.name {
+			t.Errorf("NewArchive name is %v, want %v", name, tt.name)
+		}
+
+		// Archives can be opened more than once.
+		for i := 0; i < 2; i++ {
+			if got := readArchive(t, a); !reflect.DeepEqual(got, want) {
+				t.Errorf("NewArchive(%v) contains %v, want %v", tt.name, got, want)
+			}
+		}
+	}
+}
+
+func TestNewArchive_options(t *testing.T) {
+	dir := setupTestDir(t)
+
+	a, err := NewArchive(dir, ArchiveZip, &DirectoryOptions{Include: []string{"*.jpg"}})
+	if err != nil {
+		t.Fatalf("NewArchive returned error: %v", err)
+	}
+
+	base := filepath.Base(dir)
+	want := map[string]string{
+		base + "/b.jpg":       "bb",
+		base + "/other/f.jpg": "ffffff",
+	}
+	if got := readArchive(t, a); !reflect.DeepEqual(got, want) {
+		t.Errorf("NewArchive contains %v, want %v", got, want)
+	}
+}
+
+func TestNewArchive_changed(t *testing.T) {
+	dir := filepath.Join(setupTestDir(t), "docs")
+
+	a, err := NewArchive(dir, ArchiveTarGz, nil)
+	if err != nil {
+		t.Fatalf("NewArchive returned error: %v", err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "c.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, _ := a.Open()
+	defer rc.Close()
+	if _, err := ioutil.ReadAll(rc); err == nil {
+		t.Error("Read of a changed archive returned no error")
+	}
+}
+
+func TestNewArchive_errors(t *testing.T) {
+	dir := setupTestDir(t)
+
+	if _, err := NewArchive(dir, ArchiveFormat(42), nil); err == nil {
+		t.Error("NewArchive with an unknown format returned no error")
+	}
+	if _, err := NewArchive(filepath.Join(dir, "missing"), ArchiveZip, nil); err == nil {
+		t.Error("NewArchive of a missing directory returned no error")
+	}
+}
@@ -1,25 +1,195 @@
 package wt
 
-import "context"
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// tokenRefreshMargin is how long before its expiry a token is refreshed.
+const tokenRefreshMargin = time.Minute
+
+// Token is a JWT authorization token.
+type Token struct {
+	JWT string
+
+	// Expiry is the time the token expires. If zero, it is decoded from the
+	// JWT, and a token without expiry is used until it is rejected.
+	Expiry time.Time
+}
+
+// TokenSource supplies the JWT tokens of authorized requests. Implementations
+// can get tokens from a cache or a secret store.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// TokenSourceFunc is an adapter to use an ordinary function as a TokenSource.
+type TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+// Token calls f(ctx).
+func (f TokenSourceFunc) Token(ctx context.Context) (*Token, error) {
+	return f(ctx)
+}
+
+// APITokenSource returns a TokenSource requesting new tokens from the
+// WeTransfer API with the API key of the client. It is the source used by
+// clients without a TokenSource, and can be wrapped to cache tokens.
+func APITokenSource(c *Client) TokenSource {
+	return TokenSourceFunc(c.requestToken)
+}
 
 // Authorize sets the JWT token of the WeTransfer client to issue
-// authorized requests to the API
+// authorized requests to the API. The token comes from the TokenSource of the
+// client, or from the API if there is none. Once authorized, the client
+// refreshes the token by itself before it expires or when it gets rejected.
 func Authorize(ctx context.Context, c *Client) error {
+	_, err := c.refreshToken(ctx, c.currentToken())
+	return err
+}
+
+// requestToken requests a new JWT token from the API.
+func (c *Client) requestToken(ctx context.Context) (*Token, error) {
 	req, err := c.NewRequest("POST", "authorize", nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	req.Header.Del("Authorization")
 
 	var responseMessage struct {
 		Success bool   `json:"success"`
 		Token   string `json:"token,omitempty"`
 	}
 
-	_, err = c.Do(ctx, req, &responseMessage)
+	_, err = c.send(ctx, req, &responseMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	if responseMessage.Token == "" {
+		return nil, fmt.Errorf("authorize: no token returned")
+	}
+
+	return &Token{JWT: responseMessage.Token}, nil
+}
+
+// currentToken returns the JWT token currently in use.
+func (c *Client) currentToken() string {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	return c.JWTAuthToken
+}
+
+// token returns a JWT token valid for a while, refreshing the current one if
+// it is about to expire. It returns a blank token if the client has not been
+// authorized.
+func (c *Client) token(ctx context.Context) (string, error) {
+	c.tokenMu.Lock()
+	tok := c.JWTAuthToken
+	if c.tokenFor != tok {
+		// The token was set by hand.
+		c.tokenFor, c.tokenExpiry = tok, jwtExpiry(tok)
+	}
+	exp := c.tokenExpiry
+	c.tokenMu.Unlock()
+
+	if tok == "" && c.TokenSource == nil {
+		return "", nil
+	}
+
+	if tok != "" && (exp.IsZero() || time.Until(exp) > tokenRefreshMargin) {
+		return tok, nil
+	}
+
+	return c.refreshToken(ctx, tok)
+}
+
+// refreshToken replaces a stale JWT token with a new one. Concurrent callers
+// share a single refresh, and the refresh is skipped if the stale token has
+// been replaced already. Should the caller making the refresh give up, those
+// waiting for it make another one rather than fail with its context error.
+func (c *Client) refreshToken(ctx context.Context, stale string) (string, error) {
+	c.tokenMu.Lock()
+
+	for {
+		if c.JWTAuthToken != stale {
+			tok := c.JWTAuthToken
+			c.tokenMu.Unlock()
+			return tok, nil
+		}
+
+		ch := c.refreshing
+		if ch == nil {
+			break
+		}
+
+		c.tokenMu.Unlock()
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		c.tokenMu.Lock()
+
+		if !c.refreshAbandoned {
+			defer c.tokenMu.Unlock()
+			return c.JWTAuthToken, c.refreshErr
+		}
+	}
+
+	ch := make(chan struct{})
+	c.refreshing = ch
+	c.tokenMu.Unlock()
+
+	source := c.TokenSource
+	if source == nil {
+		source = APITokenSource(c)
+	}
+	tok, err := source.Token(ctx)
+	if err == nil && (tok == nil || tok.JWT == "") {
+		err = fmt.Errorf("token source returned a blank token")
+	}
+
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if err == nil {
+		c.JWTAuthToken = tok.JWT
+		c.tokenFor, c.tokenExpiry = tok.JWT, tok.Expiry
+		if tok.Expiry.IsZero() {
+			c.tokenExpiry = jwtExpiry(tok.JWT)
+		}
+	}
+	c.refreshErr = err
+	c.refreshAbandoned = err != nil && ctx.Err() != nil
+	c.refreshing = nil
+	close(ch)
+
+	return c.JWTAuthToken, err
+}
+
+// jwtExpiry decodes the expiry of a JWT token. It returns the zero time if the
+// token has no expiry or is not a JWT.
+func jwtExpiry(tok string) time.Time {
+	parts := strings.Split(tok, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
 	if err != nil {
-		return err
+		return time.Time{}
+	}
+
+	var claims struct {
+		Exp *float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == nil {
+		return time.Time{}
 	}
 
-	c.JWTAuthToken = responseMessage.Token
-	return nil
+	return time.Unix(int64(*claims.Exp), 0)
 }
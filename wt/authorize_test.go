@@ -2,9 +2,15 @@ package wt
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestAuthorize(t *testing.T) {
@@ -44,7 +50,196 @@ func TestAuthorize_forbidden(t *testing.T) {
 		t.Errorf("Expected error to be returned")
 	}
 
-	if err, ok := err.(*ErrorResponse); !ok && err.Message != wantError {
-		t.Errorf("ErrorResponse.Message returned %v, want %+v", err.Message, wantError)
+	var authErr *AuthError
+	if !errors.As(err, &authErr) {
+		t.Errorf("Authorize returned %T, want *AuthError", err)
+	}
+
+	testErrorResponse(t, err, wantError)
+}
+
+// testJWT returns a JWT token expiring at exp.
+func testJWT(exp time.Time) string {
+	enc := base64.RawURLEncoding
+	payload := fmt.Sprintf(`{"exp":%d}`, exp.Unix())
+	return enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." + enc.EncodeToString([]byte(payload)) + ".sig"
+}
+
+func TestJWTExpiry(t *testing.T) {
+	exp := time.Unix(1893456000, 0)
+
+	tests := []struct {
+		token string
+		want  time.Time
+	}{
+		{testJWT(exp), exp},
+		{testJWTAuthToken, time.Time{}},
+		{"a.b.c", time.Time{}},
+		{"", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		if got := jwtExpiry(tt.token); !got.Equal(tt.want) {
+			t.Errorf("jwtExpiry(%q) returned %v, want %v", tt.token, got, tt.want)
+		}
+	}
+}
+
+func TestClient_Do_refreshExpiring(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	fresh := testJWT(time.Now().Add(time.Hour))
+	client.JWTAuthToken = testJWT(time.Now().Add(time.Second))
+
+	authorized := 0
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		authorized++
+		fmt.Fprintf(w, `{"success":true,"token":"%s"}`, fresh)
+	})
+	mux.HandleFunc("/transfers/1", func(w http.ResponseWriter, r *http.Request) {
+		testHeader(t, r, "Authorization", "Bearer "+fresh)
+		fmt.Fprint(w, `{"id": "1"}`)
+	})
+
+	if _, err := client.Transfers.Find(context.Background(), "1"); err != nil {
+		t.Errorf("TransfersService.Find returned an error: %v", err)
+	}
+
+	if authorized != 1 {
+		t.Errorf("Client refreshed the token %v times, want %v", authorized, 1)
+	}
+	if client.JWTAuthToken != fresh {
+		t.Errorf("Client.JWTAuthToken is %v, want %v", client.JWTAuthToken, fresh)
+	}
+}
+
+func TestClient_Do_unauthorizedReplay(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	fresh := "jwt-token-2"
+
+	authorized := 0
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		authorized++
+		testHeader(t, r, "Authorization", "")
+		fmt.Fprintf(w, `{"success":true,"token":"%s"}`, fresh)
+	})
+	mux.HandleFunc("/transfers/1/files/1/upload-complete", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+fresh {
+			w.WriteHeader(401)
+			fmt.Fprint(w, `{"success": false, "message": "Token expired"}`)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if want := `{"part_numbers":1}`; strings.TrimSpace(string(body)) != want {
+			t.Errorf("Replayed body is %s, want %s", body, want)
+		}
+		fmt.Fprint(w, `{"id": "1"}`)
+	})
+
+	tx := &Transfer{
+		ID:    String("1"),
+		Files: []*File{{ID: String("1"), Multipart: &Multipart{PartNumbers: Int64(1)}}},
+	}
+	if _, err := client.Transfers.completeFile(context.Background(), tx, tx.Files[0]); err != nil {
+		t.Errorf("TransfersService.completeFile returned an error: %v", err)
+	}
+
+	if authorized != 1 {
+		t.Errorf("Client refreshed the token %v times, want %v", authorized, 1)
+	}
+}
+
+func TestClient_Do_concurrentRefresh(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	var mu sync.Mutex
+	calls := 0
+	client.JWTAuthToken = ""
+	client.TokenSource = TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		return &Token{JWT: "from-the-vault", Expiry: time.Now().Add(time.Hour)}, nil
+	})
+
+	mux.HandleFunc("/transfers/1", func(w http.ResponseWriter, r *http.Request) {
+		testHeader(t, r, "Authorization", "Bearer from-the-vault")
+		fmt.Fprint(w, `{"id": "1"}`)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Transfers.Find(context.Background(), "1"); err != nil {
+				t.Errorf("TransfersService.Find returned an error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("TokenSource was called %v times, want %v", calls, 1)
+	}
+}
+
+func TestClient_token_abandonedRefresh(t *testing.T) {
+	client, _, _, teardown := setup()
+	defer teardown()
+
+	started := make(chan struct{})
+	var mu sync.Mutex
+	calls := 0
+	client.JWTAuthToken = ""
+	client.TokenSource = TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+		mu.Lock()
+		calls++
+		first := calls == 1
+		mu.Unlock()
+
+		if first {
+			// The first caller gives up while the token is on its way.
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return &Token{JWT: "from-the-vault", Expiry: time.Now().Add(time.Hour)}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error)
+	go func() {
+		_, err := client.token(ctx)
+		firstErr <- err
+	}()
+	<-started
+
+	type result struct {
+		tok string
+		err error
+	}
+	second := make(chan result)
+	go func() {
+		tok, err := client.token(context.Background())
+		second <- result{tok, err}
+	}()
+
+	// Let the second caller join the refresh in flight.
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("token of the canceled caller returned %v, want %v", err, context.Canceled)
+	}
+
+	got := <-second
+	if got.err != nil || got.tok != "from-the-vault" {
+		t.Errorf("token returned %q, %v, want %q, nil", got.tok, got.err, "from-the-vault")
 	}
 }
@@ -25,6 +25,10 @@ type Item struct {
 	Type      *string    `json:"type"`
 	Multipart *Multipart `json:"multipart,omitempty"`
 	Meta      *Meta      `json:"meta,omitempty"`
+
+	// Digest records what was uploaded for a file item by AddFiles. It is
+	// not part of the API responses.
+	Digest *FileDigest `json:"-"`
 }
 
 // GetName returns the Name field if it is not nil. Otherwise, it returns
@@ -53,6 +57,34 @@ func (i *Item) GetMultipart() *Multipart {
 	return i.Multipart
 }
 
+// GetURL returns the URL field of a link item if it is not nil. Otherwise, it
+// returns an empty string.
+func (i *Item) GetURL() string {
+	if i == nil || i.URL == nil {
+		return ""
+	}
+	return *i.URL
+}
+
+// GetSize returns the Size field of a file item if it is not nil. Otherwise,
+// it returns 0.
+func (i *Item) GetSize() int64 {
+	if i == nil || i.Size == nil {
+		return 0
+	}
+	return *i.Size
+}
+
+// IsLink reports whether the item is a link.
+func (i *Item) IsLink() bool {
+	return i != nil && i.Type != nil && *i.Type == "link"
+}
+
+// IsFile reports whether the item is a file.
+func (i *Item) IsFile() bool {
+	return i != nil && i.Type != nil && *i.Type == "file"
+}
+
 func (i Item) String() string {
 	return ToString(i)
 }
@@ -67,16 +99,16 @@ func (l Link) String() string {
 	return ToString(l)
 }
 
-// NewLink returns a new link given a URL and an optional title. It
-// automatically validates the URL string and returns an error if it fails
-// validation.
+// NewLink returns a new link given a URL and an optional title. The URL must
+// be an absolute http or https URL. It is normalized: the scheme and the host
+// are lowercased, default ports are removed and an empty path becomes "/".
 func NewLink(u string, title *string) (*Link, error) {
-	_, err := url.Parse(u)
+	n, err := normalizeURL(u)
 	if err != nil {
 		return nil, err
 	}
 	link := &Link{
-		URL:   &u,
+		URL:   &n,
 		Title: title,
 	}
 	return link, nil
@@ -110,6 +142,33 @@ func (b *Board) GetURL() string {
 	return *b.URL
 }
 
+// GetName returns the Name field if it is not nil. Otherwise, it returns
+// an empty string.
+func (b *Board) GetName() string {
+	if b == nil || b.Name == nil {
+		return ""
+	}
+	return *b.Name
+}
+
+// GetDesc returns the Desc field if it is not nil. Otherwise, it returns
+// an empty string.
+func (b *Board) GetDesc() string {
+	if b == nil || b.Desc == nil {
+		return ""
+	}
+	return *b.Desc
+}
+
+// GetState returns the State field if it is not nil. Otherwise, it returns
+// an empty string.
+func (b *Board) GetState() string {
+	if b == nil || b.State == nil {
+		return ""
+	}
+	return *b.State
+}
+
 func (b Board) String() string {
 	return ToString(b)
 }
@@ -120,7 +179,10 @@ type BoardsService service
 
 // Create creates an empty WeTransfer board. Name is required but description
 // is optional.
-func (b *BoardsService) Create(ctx context.Context, name string, desc *string) (*Board, error) {
+func (b *BoardsService) Create(ctx context.Context, name string, desc *string) (board *Board, err error) {
+	ctx, span := b.client.startSpan(ctx, "wt.Boards.Create")
+	defer func() { span.end(err) }()
+
 	req, err := b.client.NewRequest("POST", "boards", &struct {
 		Name string  `json:"name"`
 		Desc *string `json:"description"`
@@ -133,17 +195,22 @@ func (b *BoardsService) Create(ctx context.Context, name string, desc *string) (
 		return nil, err
 	}
 
-	board := &Board{}
+	board = &Board{}
 	if _, err := b.client.Do(ctx, req, board); err != nil {
 		return nil, err
 	}
+	span.set(attr("wt.board.id", board.GetID()))
 
 	return board, nil
 }
 
 // AddLinks creates link items for a given board. It returns a list of items
-// with meta information.
-func (b *BoardsService) AddLinks(ctx context.Context, board *Board, links ...*Link) ([]*Item, error) {
+// with meta information. If the client has a TitleResolver, the titles of
+// links without one are resolved first.
+func (b *BoardsService) AddLinks(ctx context.Context, board *Board, links ...*Link) (items []*Item, err error) {
+	ctx, span := b.client.startSpan(ctx, "wt.Boards.AddLinks", idAttr(board), attr("wt.links", len(links)))
+	defer func() { span.end(err) }()
+
 	bid := board.GetID()
 	path := fmt.Sprintf("boards/%v/links", url.PathEscape(bid))
 
@@ -157,12 +224,13 @@ func (b *BoardsService) AddLinks(ctx context.Context, board *Board, links ...*Li
 		return nil, fmt.Errorf("no links provided")
 	}
 
+	gotLinks = b.client.resolveTitles(ctx, gotLinks)
+
 	req, err := b.client.NewRequest("POST", path, gotLinks)
 	if err != nil {
 		return nil, err
 	}
 
-	var items []*Item
 	if _, err := b.client.Do(ctx, req, &items); err != nil {
 		return nil, err
 	}
@@ -170,50 +238,122 @@ func (b *BoardsService) AddLinks(ctx context.Context, board *Board, links ...*Li
 	return items, nil
 }
 
-// AddFiles uploads files to a specified board.
-func (b *BoardsService) AddFiles(ctx context.Context, board *Board, up ...Uploadable) ([]*Item, error) {
+// AddNewLinks adds the links that are not on the board yet, comparing
+// normalized URLs with the link items of board.Items. Links repeated in the
+// call are only added once. The new items are appended to board.Items and
+// returned. No request is made if all links are already on the board.
+func (b *BoardsService) AddNewLinks(ctx context.Context, board *Board, links ...*Link) ([]*Item, error) {
+	seen := make(map[string]bool)
+	for _, item := range board.Items {
+		if item.IsLink() {
+			seen[linkKey(item.GetURL())] = true
+		}
+	}
+
+	var newLinks []*Link
+	for _, link := range links {
+		if link == nil || link.URL == nil {
+			continue
+		}
+		key := linkKey(*link.URL)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		newLinks = append(newLinks, link)
+	}
+
+	if len(newLinks) == 0 {
+		return nil, nil
+	}
+
+	items, err := b.AddLinks(ctx, board, newLinks...)
+	if err != nil {
+		return nil, err
+	}
+
+	board.Items = append(board.Items, items...)
+	return items, nil
+}
+
+// AddFiles uploads files to a specified board. Uploadables are checked with
+// Validate first, and those sharing a name are handled according to the
+// DuplicateNames policy of the client. If the client has Cleanup set, the
+// items added are removed when the upload fails.
+func (b *BoardsService) AddFiles(ctx context.Context, board *Board, up ...Uploadable) (items []*Item, err error) {
+	ctx, span := b.client.startSpan(ctx, "wt.Boards.AddFiles", idAttr(board), attr("wt.files", len(up)))
+	defer func() { span.end(err) }()
+
 	if len(up) == 0 {
 		return nil, fmt.Errorf("empty files")
 	}
 
-	filemap := make(map[string]Uploadable)
-	for _, f := range up {
-		name, _ := f.Stat()
-		filemap[name] = f
+	up, err = b.client.prepareUploads(up)
+	if err != nil {
+		return nil, err
 	}
 
-	items, err := b.uploadFiles(ctx, board, up...)
+	progress := newProgressTracker(b.client.Progress, up...)
+	progress.setPhase(PhaseCreate, board.GetID())
+
+	items, err = b.uploadFiles(ctx, board, up...)
 	if err != nil {
 		return nil, err
 	}
 
+	files := make([]fileItem, len(items))
+	for i, item := range items {
+		files[i] = item
+	}
+
 	var errs []error
 
-	for _, f := range items {
-		name := f.GetName()
-		if tx, ok := filemap[name]; ok {
-			ft := newFileTransfer(tx, f)
-			err = b.client.uploader.upload(ctx, board, ft)
-			if err != nil {
-				errs = append(errs, err)
-			}
+	fts := matchFiles(up, files)
+	for i, ft := range fts {
+		if ft == nil {
+			errs = append(errs, fmt.Errorf("no uploadable for file %q", items[i].GetName()))
+			continue
 		}
+		progress.add(ft)
 	}
 
 	if len(errs) > 0 {
+		b.removeAdded(ctx, board, items)
 		return nil, joinErrors(errs, nil)
 	}
 
-	// If we have reached this stage, that means there were no errors while
-	// uploading the files/chunks. Now we attempt to complete it.
-	err = b.complete(ctx, board, items)
+	progress.setPhase(PhaseUpload, "")
+
+	// Each file is marked as complete as soon as its parts are uploaded.
+	err = b.client.uploader.uploadFiles(ctx, board, fts, func(ctx context.Context, ft *fileTransfer) error {
+		return b.completeItem(ctx, board, ft.file.(*Item))
+	})
 	if err != nil {
+		b.removeAdded(ctx, board, items)
 		return nil, err
 	}
 
+	for _, ft := range fts {
+		ft.file.(*Item).Digest = ft.digest
+	}
+
+	progress.setPhase(PhaseDone, "")
 	return items, nil
 }
 
+// removeAdded removes the items of an abandoned AddFiles if the client has
+// Cleanup set.
+func (b *BoardsService) removeAdded(ctx context.Context, board *Board, items []*Item) {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.GetID()
+	}
+
+	b.client.cleanup(ctx, "items of board "+board.GetID(), func(ctx context.Context) error {
+		return b.RemoveItems(ctx, board, ids...)
+	})
+}
+
 func (b *BoardsService) uploadFiles(ctx context.Context, board *Board, up ...Uploadable) ([]*Item, error) {
 	var fs []fileObject
 
@@ -237,44 +377,133 @@ func (b *BoardsService) uploadFiles(ctx context.Context, board *Board, up ...Upl
 	return items, nil
 }
 
-func (b *BoardsService) complete(ctx context.Context, board *Board, items []*Item) error {
+// completeItem informs WeTransfer that all the parts of a file item are
+// uploaded.
+func (b *BoardsService) completeItem(ctx context.Context, board *Board, item *Item) error {
+	bid := url.PathEscape(board.GetID())
+	fid := url.PathEscape(item.GetID())
+	path := fmt.Sprintf("boards/%v/files/%v/upload-complete", bid, fid)
+
+	req, err := b.client.NewRequest("PUT", path, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.client.Do(ctx, req, nil)
+	return err
+}
+
+// Find retrieves a board given an id.
+func (b *BoardsService) Find(ctx context.Context, id string) (board *Board, err error) {
+	ctx, span := b.client.startSpan(ctx, "wt.Boards.Find", attr("wt.board.id", id))
+	defer func() { span.end(err) }()
+
+	path := fmt.Sprintf("boards/%v", url.PathEscape(id))
+
+	req, err := b.client.NewRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	board = &Board{}
+	if _, err = b.client.Do(ctx, req, board); err != nil {
+		return nil, err
+	}
+
+	return board, nil
+}
+
+// Update renames and re-describes a board. A nil name or description is left
+// unchanged. It returns the updated board.
+func (b *BoardsService) Update(ctx context.Context, board *Board, name, desc *string) (updated *Board, err error) {
+	ctx, span := b.client.startSpan(ctx, "wt.Boards.Update", idAttr(board))
+	defer func() { span.end(err) }()
+
+	if name == nil && desc == nil {
+		return nil, fmt.Errorf("nothing to update")
+	}
+	if name != nil && *name == "" {
+		return nil, fmt.Errorf("board name must not be blank")
+	}
+
+	path := fmt.Sprintf("boards/%v", url.PathEscape(board.GetID()))
+
+	req, err := b.client.NewRequest("PATCH", path, &struct {
+		Name *string `json:"name,omitempty"`
+		Desc *string `json:"description,omitempty"`
+	}{
+		Name: name,
+		Desc: desc,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	updated = &Board{}
+	if _, err = b.client.Do(ctx, req, updated); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// RemoveItems removes links and files from a board given their ids. Removed
+// items are also taken out of board.Items.
+func (b *BoardsService) RemoveItems(ctx context.Context, board *Board, ids ...string) (err error) {
+	ctx, span := b.client.startSpan(ctx, "wt.Boards.RemoveItems", idAttr(board), attr("wt.items", len(ids)))
+	defer func() { span.end(err) }()
+
+	if len(ids) == 0 {
+		return fmt.Errorf("no items provided")
+	}
+
 	var errs []error
 
 	bid := url.PathEscape(board.GetID())
-	for _, item := range items {
-		fid := url.PathEscape(item.GetID())
-		path := fmt.Sprintf("boards/%v/files/%v/upload-complete", bid, fid)
-		req, err := b.client.NewRequest("PUT", path, nil)
+	removed := make(map[string]bool, len(ids))
+
+	for _, id := range ids {
+		path := fmt.Sprintf("boards/%v/items/%v", bid, url.PathEscape(id))
+		req, err := b.client.NewRequest("DELETE", path, nil)
 		if err != nil {
 			errs = append(errs, err)
 			continue
 		}
 		if _, err = b.client.Do(ctx, req, nil); err != nil {
 			errs = append(errs, err)
+			continue
+		}
+		removed[id] = true
+	}
+
+	items := board.Items[:0]
+	for _, item := range board.Items {
+		if !removed[item.GetID()] {
+			items = append(items, item)
 		}
 	}
+	board.Items = items
 
 	if len(errs) > 0 {
-		errmsg := fmt.Sprintf("completing transfer %v, with %v error(s)", bid, len(errs))
+		errmsg := fmt.Sprintf("removing items of board %v, with %v error(s)", bid, len(errs))
 		return joinErrors(errs, &errmsg)
 	}
 
 	return nil
 }
 
-// Find retrieves a board given an id.
-func (b *BoardsService) Find(ctx context.Context, id string) (*Board, error) {
-	path := fmt.Sprintf("boards/%v", url.PathEscape(id))
+// Delete removes a board along with its items.
+func (b *BoardsService) Delete(ctx context.Context, board *Board) (err error) {
+	ctx, span := b.client.startSpan(ctx, "wt.Boards.Delete", idAttr(board))
+	defer func() { span.end(err) }()
 
-	req, err := b.client.NewRequest("GET", path, nil)
-	if err != nil {
-		return nil, err
-	}
+	path := fmt.Sprintf("boards/%v", url.PathEscape(board.GetID()))
 
-	board := &Board{}
-	if _, err = b.client.Do(ctx, req, board); err != nil {
-		return nil, err
+	req, err := b.client.NewRequest("DELETE", path, nil)
+	if err != nil {
+		return err
 	}
 
-	return board, nil
+	_, err = b.client.Do(ctx, req, nil)
+	return err
 }
@@ -2,9 +2,13 @@ package wt
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -199,7 +203,7 @@ func TestBoardsService_Find(t *testing.T) {
 	}
 }
 
-func TestBoardsService_complete(t *testing.T) {
+func TestBoardsService_completeItem(t *testing.T) {
 	client, mux, _, teardown := setup()
 	defer teardown()
 
@@ -227,13 +231,14 @@ func TestBoardsService_complete(t *testing.T) {
 		})
 	}
 
-	err := client.Boards.complete(context.Background(), board, items)
-	if err != nil {
-		t.Errorf("Boards.complete returned an error %v", err)
+	for _, item := range items {
+		if err := client.Boards.completeItem(context.Background(), board, item); err != nil {
+			t.Errorf("Boards.completeItem returned an error %v", err)
+		}
 	}
 }
 
-func TestBoardsService_complete_badRequest(t *testing.T) {
+func TestBoardsService_completeItem_badRequest(t *testing.T) {
 	client, mux, _, teardown := setup()
 	defer teardown()
 
@@ -249,13 +254,11 @@ func TestBoardsService_complete_badRequest(t *testing.T) {
 		ID:    String("1"),
 		Items: []*Item{},
 	}
-	items := []*Item{
-		{
-			ID: String("1"),
-		},
+	item := &Item{
+		ID: String("1"),
 	}
 
-	err := client.Boards.complete(context.Background(), board, items)
+	err := client.Boards.completeItem(context.Background(), board, item)
 	if err == nil {
 		t.Error("Expected error to be returned")
 	}
@@ -284,3 +287,175 @@ func TestBoardsService_Find_forbidden(t *testing.T) {
 
 	testErrorResponse(t, err, wantError)
 }
+
+func TestBoardsService_Update(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/boards/board-id", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PATCH")
+
+		b, _ := ioutil.ReadAll(r.Body)
+		if got, want := string(b), `{"description":"Cats only"}`+"\n"; got != want {
+			t.Errorf("Request body is %v, want %v", got, want)
+		}
+
+		fmt.Fprint(w, `{
+			"id": "board-id",
+			"name": "Little kittens",
+			"description": "Cats only",
+			"state": "downloadable",
+			"items": []
+		}`)
+	})
+
+	board, err := client.Boards.Update(context.Background(), &Board{ID: String("board-id")}, nil, String("Cats only"))
+	if err != nil {
+		t.Fatalf("Boards.Update returned an error: %v", err)
+	}
+
+	if got, want := board.GetDesc(), "Cats only"; got != want {
+		t.Errorf("Boards.Update description is %v, want %v", got, want)
+	}
+	if got, want := board.GetName(), "Little kittens"; got != want {
+		t.Errorf("Boards.Update name is %v, want %v", got, want)
+	}
+}
+
+func TestBoardsService_Update_nothing(t *testing.T) {
+	client, _, _, teardown := setup()
+	defer teardown()
+
+	board := &Board{ID: String("board-id")}
+	for _, name := range []*string{nil, String("")} {
+		if _, err := client.Boards.Update(context.Background(), board, name, nil); err == nil {
+			t.Error("Expected error to be returned")
+		}
+	}
+}
+
+func TestBoardsService_RemoveItems(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/boards/board-id/items/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/boards/board-id/items/2", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"success": false, "message": "Item not found"}`)
+	})
+
+	board := &Board{
+		ID:    String("board-id"),
+		Items: []*Item{{ID: String("1")}, {ID: String("2")}, {ID: String("3")}},
+	}
+
+	err := client.Boards.RemoveItems(context.Background(), board, "1", "2")
+
+	var nf *NotFoundError
+	if !errors.As(err, &nf) {
+		t.Errorf("Boards.RemoveItems returned %v, want *NotFoundError", err)
+	}
+
+	var ids []string
+	for _, item := range board.Items {
+		ids = append(ids, item.GetID())
+	}
+	if want := []string{"2", "3"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Boards.RemoveItems left items %v, want %v", ids, want)
+	}
+}
+
+func TestBoardsService_Delete(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	var called bool
+	mux.HandleFunc("/boards/board-id", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		called = true
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if err := client.Boards.Delete(context.Background(), &Board{ID: String("board-id")}); err != nil {
+		t.Errorf("Boards.Delete returned an error: %v", err)
+	}
+	if !called {
+		t.Error("Boards.Delete did not send a request")
+	}
+}
+
+func TestBoardsService_AddFiles_cleanup(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	client.RetryPolicy = nil
+	client.Cleanup = true
+
+	mux.HandleFunc("/boards/board-id/files", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"id": "1", "name": "a.txt", "size": 1, "type": "file", "multipart": {"id": "m1", "part_numbers": 1, "chunk_size": 1}},
+			{"id": "2", "name": "b.txt", "size": 1, "type": "file", "multipart": {"id": "m2", "part_numbers": 1, "chunk_size": 1}}
+		]`)
+	})
+	mux.HandleFunc("/boards/board-id/files/1/upload-url/1/m1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/boards/board-id/files/2/upload-url/1/m2", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	var (
+		mu      sync.Mutex
+		removed []string
+	)
+	mux.HandleFunc("/boards/board-id/items/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		mu.Lock()
+		removed = append(removed, strings.TrimPrefix(r.URL.Path, "/boards/board-id/items/"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	board := &Board{ID: String("board-id")}
+	_, err := client.Boards.AddFiles(context.Background(), board,
+		NewBuffer("a.txt", []byte("a")), NewBuffer("b.txt", []byte("b")))
+	if err == nil {
+		t.Fatal("Boards.AddFiles returned no error")
+	}
+
+	if want := []string{"1", "2"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("Boards.AddFiles removed items %v, want %v", removed, want)
+	}
+}
+
+func TestItem_accessors(t *testing.T) {
+	link := &Item{Type: String("link"), URL: String("https://wetransfer.com")}
+	file := &Item{Type: String("file"), Size: Int64(42)}
+
+	if !link.IsLink() || link.IsFile() || link.GetURL() != "https://wetransfer.com" {
+		t.Errorf("link item accessors returned %v, %v, %v", link.IsLink(), link.IsFile(), link.GetURL())
+	}
+	if !file.IsFile() || file.IsLink() || file.GetSize() != 42 {
+		t.Errorf("file item accessors returned %v, %v, %v", file.IsFile(), file.IsLink(), file.GetSize())
+	}
+
+	var nilItem *Item
+	if nilItem.IsLink() || nilItem.IsFile() || nilItem.GetURL() != "" || nilItem.GetSize() != 0 {
+		t.Error("nil item accessors did not return zero values")
+	}
+}
+
+func TestBoard_accessors(t *testing.T) {
+	b := &Board{Name: String("n"), Desc: String("d"), State: String("s")}
+	if b.GetName() != "n" || b.GetDesc() != "d" || b.GetState() != "s" {
+		t.Errorf("Board accessors returned %v, %v, %v", b.GetName(), b.GetDesc(), b.GetState())
+	}
+
+	var nilBoard *Board
+	if nilBoard.GetName() != "" || nilBoard.GetDesc() != "" || nilBoard.GetState() != "" {
+		t.Error("nil board accessors did not return blank strings")
+	}
+}
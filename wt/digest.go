@@ -0,0 +1,106 @@
+package wt
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+)
+
+// FileDigest records what was uploaded for a file, to be kept along with the
+// transfer or board and checked against the content later on.
+type FileDigest struct {
+	FileID string
+	Name   string
+	Size   int64
+
+	// Parts holds the digest of each part, by part number minus one. Parts
+	// uploaded by an earlier attempt, such as a resumed session, are nil.
+	Parts []*PartDigest
+
+	// MD5 and SHA256 are the hex encoded digests of the whole file. They are
+	// blank if some parts were uploaded by an earlier attempt, as their
+	// content was not read.
+	MD5    string
+	SHA256 string
+}
+
+// PartDigest records what was uploaded for a part of a file.
+type PartDigest struct {
+	PartNumber int64
+	Size       int64
+
+	// MD5 is the hex encoded digest of the part, sent to the storage as the
+	// Content-MD5 header of the upload.
+	MD5 string
+
+	// ETag is the entity tag the storage returned for the part, if any. It
+	// is recorded as is: storages such as S3 with server-side encryption
+	// return ETags which are not the MD5 of the part.
+	ETag string
+}
+
+// fileDigester computes the digests of a whole file from its parts, which may
+// be read concurrently but are hashed in order.
+type fileDigester struct {
+	md5    hash.Hash
+	sha256 hash.Hash
+	w      io.Writer
+
+	// turns[n-1] is closed once part n may be hashed.
+	turns  []chan struct{}
+	hashed int // number of parts hashed
+}
+
+func newFileDigester(parts int64) *fileDigester {
+	d := &fileDigester{
+		md5:    md5.New(),
+		sha256: sha256.New(),
+		turns:  make([]chan struct{}, parts),
+	}
+	d.w = io.MultiWriter(d.md5, d.sha256)
+
+	for i := range d.turns {
+		d.turns[i] = make(chan struct{})
+	}
+	if parts > 0 {
+		close(d.turns[0])
+	}
+
+	return d
+}
+
+// add hashes part n once the parts before it are hashed. A nil digester does
+// nothing. ok is false if the part could not be read, in which case it is
+// passed over and the digests are unavailable.
+func (d *fileDigester) add(ctx context.Context, n int64, data []byte, ok bool) {
+	if d == nil {
+		return
+	}
+
+	select {
+	case <-d.turns[n-1]:
+	case <-ctx.Done():
+		return
+	}
+
+	if ok {
+		d.w.Write(data)
+		d.hashed++
+	}
+
+	if n < int64(len(d.turns)) {
+		close(d.turns[n])
+	}
+}
+
+// sum returns the hex encoded MD5 and SHA-256 of the file, or blanks unless
+// every part has been hashed. It must not be called while parts are added.
+func (d *fileDigester) sum() (string, string) {
+	if d == nil || d.hashed != len(d.turns) {
+		return "", ""
+	}
+	return hex.EncodeToString(d.md5.Sum(nil)), hex.EncodeToString(d.sha256.Sum(nil))
+}
@@ -0,0 +1,60 @@
+package wt
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"testing"
+)
+
+func TestFileDigester(t *testing.T) {
+	parts := []string{"0123", "4567", "89"}
+
+	d := newFileDigester(int64(len(parts)))
+
+	// Parts are added concurrently, the last one first.
+	var wg sync.WaitGroup
+	for i := len(parts); i >= 1; i-- {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			d.add(context.Background(), int64(n), []byte(parts[n-1]), true)
+		}(i)
+	}
+	wg.Wait()
+
+	wantMD5 := md5.Sum([]byte("0123456789"))
+	wantSHA := sha256.Sum256([]byte("0123456789"))
+
+	gotMD5, gotSHA := d.sum()
+	if gotMD5 != hex.EncodeToString(wantMD5[:]) {
+		t.Errorf("sum returned MD5 %v, want %x", gotMD5, wantMD5)
+	}
+	if gotSHA != hex.EncodeToString(wantSHA[:]) {
+		t.Errorf("sum returned SHA-256 %v, want %x", gotSHA, wantSHA)
+	}
+}
+
+func TestFileDigester_incomplete(t *testing.T) {
+	ctx := context.Background()
+
+	d := newFileDigester(3)
+	d.add(ctx, 1, []byte("0123"), true)
+	d.add(ctx, 2, nil, false)
+	d.add(ctx, 3, []byte("89"), true)
+
+	if gotMD5, gotSHA := d.sum(); gotMD5 != "" || gotSHA != "" {
+		t.Errorf("sum returned %q and %q, want blanks", gotMD5, gotSHA)
+	}
+
+	// Part 3 is never added.
+	d = newFileDigester(3)
+	d.add(ctx, 1, []byte("0123"), true)
+	d.add(ctx, 2, []byte("4567"), true)
+
+	if gotMD5, _ := d.sum(); gotMD5 != "" {
+		t.Errorf("sum returned %q, want a blank", gotMD5)
+	}
+}
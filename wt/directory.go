@@ -0,0 +1,188 @@
+package wt
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SymlinkPolicy tells what to do with the symbolic links found in a
+// directory.
+type SymlinkPolicy int
+
+const (
+	// SymlinkSkip ignores symbolic links. It is the default.
+	SymlinkSkip SymlinkPolicy = iota
+
+	// SymlinkFollow uploads the files symbolic links point to, and walks the
+	// directories they point to. Links to directories already walked are
+	// ignored.
+	SymlinkFollow
+
+	// SymlinkError fails on the first symbolic link.
+	SymlinkError
+)
+
+// DirectoryOptions selects the files of a directory.
+type DirectoryOptions struct {
+	// Include lists path.Match patterns of the files to upload. A pattern
+	// matches the slash-separated path of a file relative to the directory,
+	// or its base name, so "*.jpg" matches JPEG files at any depth. Empty
+	// means all files.
+	Include []string
+
+	// Exclude lists patterns of the files and directories to leave out,
+	// matched the same way. An excluded directory is not walked.
+	Exclude []string
+
+	// Symlinks tells what to do with symbolic links.
+	Symlinks SymlinkPolicy
+
+	// Hidden includes the files and directories whose name starts with a
+	// dot. They are left out by default.
+	Hidden bool
+}
+
+// dirEntry is a file found in a directory.
+type dirEntry struct {
+	path string // path on disk
+	rel  string // slash-separated path relative to the directory
+	info os.FileInfo
+}
+
+// NewDirectory returns a LocalFile for each file of the directory at root,
+// walked recursively in lexical order. Uploadables are named after the base
+// name of their file since the API does not keep directories; use NewArchive
+// to keep the structure of the directory.
+func NewDirectory(root string, opts *DirectoryOptions) ([]Uploadable, error) {
+	entries, err := walkDirectory(root, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	up := make([]Uploadable, 0, len(entries))
+	for _, e := range entries {
+		up = append(up, &LocalFile{
+			name:     sanitizeString(e.info.Name()),
+			size:     e.info.Size(),
+			filepath: e.path,
+		})
+	}
+	return up, nil
+}
+
+// walkDirectory returns the files of the directory at root selected by opts.
+func walkDirectory(root string, opts *DirectoryOptions) ([]*dirEntry, error) {
+	if opts == nil {
+		opts = &DirectoryOptions{}
+	}
+	for _, p := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", p, err)
+		}
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%v is not a directory", root)
+	}
+
+	w := &dirWalker{opts: opts, visited: make(map[string]bool)}
+	if err := w.walk(root, ""); err != nil {
+		return nil, err
+	}
+	return w.entries, nil
+}
+
+type dirWalker struct {
+	opts    *DirectoryOptions
+	visited map[string]bool // real paths of the directories walked
+	entries []*dirEntry
+}
+
+// walk walks the directory at dir, whose path relative to the root is rel.
+func (w *dirWalker) walk(dir, rel string) error {
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	if w.visited[real] {
+		return nil
+	}
+	w.visited[real] = true
+
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	names, err := f.Readdirnames(-1)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := filepath.Join(dir, name)
+		r := path.Join(rel, name)
+
+		if !w.opts.Hidden && strings.HasPrefix(name, ".") {
+			continue
+		}
+		if matchAny(w.opts.Exclude, r) {
+			continue
+		}
+
+		info, err := os.Lstat(p)
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			switch w.opts.Symlinks {
+			case SymlinkSkip:
+				continue
+			case SymlinkError:
+				return fmt.Errorf("%v is a symbolic link", p)
+			}
+			if info, err = os.Stat(p); err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case info.IsDir():
+			if err := w.walk(p, r); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			if len(w.opts.Include) > 0 && !matchAny(w.opts.Include, r) {
+				continue
+			}
+			w.entries = append(w.entries, &dirEntry{path: p, rel: r, info: info})
+		}
+	}
+
+	return nil
+}
+
+// matchAny reports whether a relative path, or its base name, matches one of
+// the patterns.
+func matchAny(patterns []string, rel string) bool {
+	base := path.Base(rel)
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, base); ok {
+			return true
+		}
+	}
+	return false
+}
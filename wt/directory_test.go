@@ -0,0 +1,144 @@
+package wt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// setupTestDir creates a directory tree and returns its path.
+func setupTestDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"a.txt":            "a",
+		"b.jpg":            "bb",
+		".env":             "secret",
+		"docs/c.txt":       "ccc",
+		"docs/d.pdf":       "dddd",
+		"docs/tmp/e.txt":   "eeeee",
+		".git/config":      "git",
+		"other/f.jpg":      "ffffff",
+		"other/nested/g.x": "g",
+	} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func uploadableNames(up []Uploadable) []string {
+	var names []string
+	for _, u := range up {
+		name, _ := u.Stat()
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestNewDirectory(t *testing.T) {
+	dir := setupTestDir(t)
+
+	tests := []struct {
+		opts *DirectoryOptions
+		want []string
+	}{
+		{nil, []string{"a.txt", "b.jpg", "c.txt", "d.pdf", "e.txt", "f.jpg", "g.x"}},
+		{&DirectoryOptions{Include: []string{"*.txt"}}, []string{"a.txt", "c.txt", "e.txt"}},
+		{&DirectoryOptions{Include: []string{"docs/*"}}, []string{"c.txt", "d.pdf"}},
+		{&DirectoryOptions{Exclude: []string{"tmp", "other"}}, []string{"a.txt", "b.jpg", "c.txt", "d.pdf"}},
+		{&DirectoryOptions{Include: []string{"*.txt"}, Exclude: []string{"docs"}}, []string{"a.txt"}},
+		{&DirectoryOptions{Hidden: true, Include: []string{".*", "config"}}, []string{".env", "config"}},
+	}
+
+	for _, tt := range tests {
+		up, err := NewDirectory(dir, tt.opts)
+		if err != nil {
+			t.Fatalf("NewDirectory returned error: %v", err)
+		}
+		if got := uploadableNames(up); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("NewDirectory(%+v) returned %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestNewDirectory_content(t *testing.T) {
+	dir := setupTestDir(t)
+
+	up, err := NewDirectory(dir, &DirectoryOptions{Include: []string{"d.pdf"}})
+	if err != nil {
+		t.Fatalf("NewDirectory returned error: %v", err)
+	}
+	testUploadable(t, up[0], "d.pdf", "dddd")
+}
+
+func TestNewDirectory_symlinks(t *testing.T) {
+	dir := setupTestDir(t)
+
+	if err := os.Symlink(filepath.Join(dir, "docs"), filepath.Join(dir, "link")); err != nil {
+		t.Skipf("symbolic links are not supported: %v", err)
+	}
+	// A cycle is only walked once.
+	if err := os.Symlink(dir, filepath.Join(dir, "other", "loop")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		policy SymlinkPolicy
+		want   []string
+	}{
+		{SymlinkSkip, []string{"c.txt", "e.txt"}},
+		{SymlinkFollow, []string{"c.txt", "e.txt"}},
+	}
+
+	for _, tt := range tests {
+		up, err := NewDirectory(dir, &DirectoryOptions{Symlinks: tt.policy, Include: []string{"*.txt"}, Exclude: []string{"a.txt"}})
+		if err != nil {
+			t.Fatalf("NewDirectory returned error: %v", err)
+		}
+		if got := uploadableNames(up); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("NewDirectory with policy %v returned %v, want %v", tt.policy, got, tt.want)
+		}
+	}
+
+	// Links to files are followed.
+	if err := os.Symlink(filepath.Join(dir, "a.txt"), filepath.Join(dir, "docs", "z.txt")); err != nil {
+		t.Fatal(err)
+	}
+	up, err := NewDirectory(filepath.Join(dir, "docs"), &DirectoryOptions{Symlinks: SymlinkFollow})
+	if err != nil {
+		t.Fatalf("NewDirectory returned error: %v", err)
+	}
+	if got, want := uploadableNames(up), []string{"c.txt", "d.pdf", "e.txt", "z.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("NewDirectory returned %v, want %v", got, want)
+	}
+
+	if _, err := NewDirectory(dir, &DirectoryOptions{Symlinks: SymlinkError}); err == nil {
+		t.Error("NewDirectory with SymlinkError returned no error")
+	}
+}
+
+func TestNewDirectory_errors(t *testing.T) {
+	dir := setupTestDir(t)
+
+	for _, tt := range []struct {
+		root string
+		opts *DirectoryOptions
+	}{
+		{filepath.Join(dir, "missing"), nil},
+		{filepath.Join(dir, "a.txt"), nil},
+		{dir, &DirectoryOptions{Include: []string{"["}}},
+	} {
+		if _, err := NewDirectory(tt.root, tt.opts); err == nil {
+			t.Errorf("NewDirectory(%v, %+v) returned no error", tt.root, tt.opts)
+		}
+	}
+}
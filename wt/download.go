@@ -0,0 +1,289 @@
+package wt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// partialSuffix is appended to the names of the files DownloadAll writes to
+// until they are complete.
+const partialSuffix = ".part"
+
+// DownloadURL represents a response of a download URL retrieval request
+type DownloadURL struct {
+	Success *bool   `json:"success"`
+	URL     *string `json:"url"`
+}
+
+// GetURL returns the URL field if it is not nil. Otherwise, it returns
+// an empty string.
+func (d *DownloadURL) GetURL() string {
+	if d == nil || d.URL == nil {
+		return ""
+	}
+	return *d.URL
+}
+
+// String returns d with the query of its URL, which holds its signature,
+// redacted.
+func (d DownloadURL) String() string {
+	if d.URL != nil {
+		d.URL = String(redactURLString(*d.URL))
+	}
+	return ToString(d)
+}
+
+// Download writes the content of a file of a transfer to w. The file is
+// streamed from the storage. If the stream is interrupted, the download picks
+// up where it stopped with a range request according to the RetryPolicy of the
+// client. The number of bytes written is verified against the size of the file.
+func (t *TransfersService) Download(ctx context.Context, transfer *Transfer, fileID string, w io.Writer) (err error) {
+	ctx, span := t.client.startSpan(ctx, "wt.Transfers.Download", idAttr(transfer), attr("wt.file.id", fileID))
+	defer func() { span.end(err) }()
+
+	file := transfer.findFile(fileID)
+	if file == nil {
+		return fmt.Errorf("file %v not found in transfer %v", fileID, transfer.GetID())
+	}
+
+	return t.download(ctx, transfer, file, w, 0)
+}
+
+// DownloadAll downloads all files of a transfer to the directory dir, which is
+// created if needed. Files are named after the file names of the transfer.
+// Each file is written to the same name with a ".part" suffix first, and
+// renamed once complete, replacing any file of the same name. A ".part" file
+// left by an interrupted download is resumed.
+func (t *TransfersService) DownloadAll(ctx context.Context, transfer *Transfer, dir string) (err error) {
+	ctx, span := t.client.startSpan(ctx, "wt.Transfers.DownloadAll", idAttr(transfer))
+	defer func() { span.end(err) }()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, f := range transfer.Files {
+		if err := t.downloadFile(ctx, transfer, f, dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		errmsg := fmt.Sprintf("download %v failed with %v error(s)", transfer.GetID(), len(errs))
+		return joinErrors(errs, &errmsg)
+	}
+
+	return nil
+}
+
+// downloadFile downloads a file of a transfer into dir, through a ".part" file
+// which is resumed if it exists already.
+func (t *TransfersService) downloadFile(ctx context.Context, transfer *Transfer, f *File, dir string) error {
+	// Never let a file name escape dir.
+	name := filepath.Base(filepath.Clean("/" + f.GetName()))
+	if name == "/" || name == "." {
+		return fmt.Errorf("invalid file name %q", f.GetName())
+	}
+
+	path := filepath.Join(dir, name)
+	partial := path + partialSuffix
+
+	out, err := os.OpenFile(partial, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	info, err := out.Stat()
+	if err != nil {
+		return err
+	}
+
+	offset := info.Size()
+	if f.Size != nil && offset > f.GetSize() {
+		offset = 0
+	}
+
+	// The download may have completed without the file being renamed.
+	if f.Size == nil || offset < f.GetSize() {
+		if err := out.Truncate(offset); err != nil {
+			return err
+		}
+		if _, err := out.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		if err := t.download(ctx, transfer, f, out, offset); err != nil {
+			return err
+		}
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(partial, path)
+}
+
+// download streams a file of a transfer to w, starting at offset.
+func (t *TransfersService) download(ctx context.Context, transfer *Transfer, f *File, w io.Writer, offset int64) (err error) {
+	ctx, span := t.client.startSpan(ctx, "wt.file",
+		idAttr(transfer),
+		attr("wt.file.id", f.GetID()),
+		attr("wt.file.name", f.GetName()),
+		attr("wt.bytes", f.GetSize()))
+	defer func() { span.end(err) }()
+
+	policy := t.client.RetryPolicy
+
+	var durl *DownloadURL
+
+	written := offset
+	failures := 0
+	for {
+		var err error
+		if durl == nil {
+			durl, err = t.getDownloadURL(ctx, transfer, f.GetID())
+			if err != nil {
+				return err
+			}
+		}
+
+		n, resp, err := t.downloadBytes(ctx, durl, w, written, failures+1, "file_id", f.GetID())
+		written += n
+		if err == nil {
+			break
+		}
+
+		// Failures are counted since the last time some bytes went through.
+		if n > 0 {
+			failures = 0
+		}
+		failures++
+
+		if resp != nil && resp.StatusCode == http.StatusForbidden {
+			// Presigned URLs are rejected with a 403 once they expire.
+			durl = nil
+			if failures >= policy.maxAttempts() || ctx.Err() != nil {
+				return err
+			}
+		} else if !policy.shouldRetry(ctx, failures, resp, err) {
+			return err
+		}
+
+		if err := policy.wait(ctx, failures, resp); err != nil {
+			return err
+		}
+		t.client.retried("download")
+	}
+
+	if f.Size != nil && written != f.GetSize() {
+		return fmt.Errorf("download of file %v got %d bytes, want %d", f.GetID(), written, f.GetSize())
+	}
+
+	return nil
+}
+
+// getDownloadURL retrieves a download URL of a file of a transfer.
+func (t *TransfersService) getDownloadURL(ctx context.Context, transfer *Transfer, fid string) (*DownloadURL, error) {
+	path := fmt.Sprintf("transfers/%v/files/%v/download-url",
+		url.PathEscape(transfer.GetID()), url.PathEscape(fid))
+
+	req, err := t.client.NewRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var durl DownloadURL
+	if _, err = t.client.Do(ctx, req, &durl); err != nil {
+		return nil, err
+	}
+
+	return &durl, nil
+}
+
+// downloadBytes copies the content at durl, starting at offset, to w, and
+// logs the download with args. It returns the number of bytes written, and
+// the response if the storage rejected the request.
+func (t *TransfersService) downloadBytes(ctx context.Context, durl *DownloadURL, w io.Writer, offset int64, attempt int, args ...interface{}) (n int64, resp *http.Response, err error) {
+	u := durl.GetURL()
+	if u == "" {
+		return 0, nil, fmt.Errorf("blank URL")
+	}
+
+	ctx, span := t.client.startSpan(ctx, "wt.storage.get",
+		attr("wt.attempt", attempt),
+		attr("wt.offset", offset))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		err = redactError(err)
+		span.end(err)
+		return 0, nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	span.set(attr("url.path", req.URL.Path))
+	t.client.inject(ctx, req)
+
+	var r *http.Response
+	start := time.Now()
+	defer func() {
+		args = append(args, "offset", offset, "bytes", n)
+		t.client.logRequest(ctx, "file download", req, r, start, attempt, err, args...)
+		t.client.count(MetricDownloadBytes, float64(n))
+		if r != nil {
+			span.set(attr("http.response.status_code", r.StatusCode))
+		}
+		span.set(attr("wt.bytes", n))
+		span.end(err)
+	}()
+
+	r, err = t.client.storage.Do(req)
+	if err != nil {
+		// If we got an error, and the context has been canceled,
+		// the context's error is probably more useful.
+		select {
+		case <-ctx.Done():
+			return 0, nil, ctx.Err()
+		default:
+		}
+		return 0, nil, redactError(err)
+	}
+	defer r.Body.Close()
+
+	switch {
+	case r.StatusCode == http.StatusPartialContent:
+	case r.StatusCode == http.StatusOK:
+		// The range was ignored, skip what was downloaded already.
+		if _, err := io.CopyN(ioutil.Discard, r.Body, offset); err != nil {
+			return 0, nil, err
+		}
+	default:
+		return 0, r, &StorageError{Response: r}
+	}
+
+	n, err = io.Copy(w, r.Body)
+	return n, nil, err
+}
+
+// findFile returns the file of a transfer with the given ID, or nil.
+func (t *Transfer) findFile(id string) *File {
+	if t == nil {
+		return nil
+	}
+	for _, f := range t.Files {
+		if f.GetID() == id {
+			return f
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package wt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testDownloadTransfer(size int64) *Transfer {
+	return &Transfer{
+		ID: String("1"),
+		Files: []*File{
+			{
+				ID:   String("1"),
+				Name: String("pony.txt"),
+				Size: Int64(size),
+			},
+		},
+	}
+}
+
+func TestTransfersService_Download(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	content := "yeehaaa"
+
+	mux.HandleFunc("/transfers/1/files/1/download-url", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprintf(w, `{"success": true, "url": "%v/s3/pony.txt"}`, srvURL)
+	})
+	mux.HandleFunc("/s3/pony.txt", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "pony.txt", time.Time{}, strings.NewReader(content))
+	})
+
+	var buf bytes.Buffer
+	err := client.Transfers.Download(context.Background(), testDownloadTransfer(int64(len(content))), "1", &buf)
+	if err != nil {
+		t.Errorf("TransfersService.Download returned an error: %v", err)
+	}
+
+	if buf.String() != content {
+		t.Errorf("TransfersService.Download wrote %q, want %q", buf.String(), content)
+	}
+}
+
+func TestTransfersService_Download_interrupted(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	client.RetryPolicy = &RetryPolicy{MaxAttempts: 2}
+
+	content := "yeehaaa"
+	attempts := 0
+	var gotRange string
+
+	mux.HandleFunc("/transfers/1/files/1/download-url", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"success": true, "url": "%v/s3/pony.txt"}`, srvURL)
+	})
+	mux.HandleFunc("/s3/pony.txt", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			// Send half of the content and drop the connection.
+			w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+			w.Write([]byte(content[:3]))
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		gotRange = r.Header.Get("Range")
+		http.ServeContent(w, r, "pony.txt", time.Time{}, strings.NewReader(content))
+	})
+
+	var buf bytes.Buffer
+	err := client.Transfers.Download(context.Background(), testDownloadTransfer(int64(len(content))), "1", &buf)
+	if err != nil {
+		t.Errorf("TransfersService.Download returned an error: %v", err)
+	}
+
+	if buf.String() != content {
+		t.Errorf("TransfersService.Download wrote %q, want %q", buf.String(), content)
+	}
+	if want := "bytes=3-"; gotRange != want {
+		t.Errorf("TransfersService.Download resumed with range %q, want %q", gotRange, want)
+	}
+}
+
+func TestTransfersService_Download_sizeMismatch(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/transfers/1/files/1/download-url", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"success": true, "url": "%v/s3/pony.txt"}`, srvURL)
+	})
+	mux.HandleFunc("/s3/pony.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "yee")
+	})
+
+	err := client.Transfers.Download(context.Background(), testDownloadTransfer(7), "1", ioutil.Discard)
+	if err == nil {
+		t.Errorf("Expected error to be returned")
+	}
+}
+
+func TestTransfersService_Download_unknownFile(t *testing.T) {
+	client, _, _, teardown := setup()
+	defer teardown()
+
+	err := client.Transfers.Download(context.Background(), testDownloadTransfer(7), "2", ioutil.Discard)
+	if err == nil {
+		t.Errorf("Expected error to be returned")
+	}
+}
+
+func TestTransfersService_DownloadAll(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	content := "yeehaaa"
+	var gotRange string
+
+	mux.HandleFunc("/transfers/1/files/1/download-url", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"success": true, "url": "%v/s3/pony.txt"}`, srvURL)
+	})
+	mux.HandleFunc("/s3/pony.txt", func(w http.ResponseWriter, r *http.Request) {
+		gotRange = r.Header.Get("Range")
+		http.ServeContent(w, r, "pony.txt", time.Time{}, strings.NewReader(content))
+	})
+
+	dir, err := ioutil.TempDir("", "wt-go-sdk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A previous download stopped half way, and an unrelated file of the
+	// same name and size is in the way.
+	name := filepath.Join(dir, "pony.txt")
+	ioutil.WriteFile(name+".part", []byte(content[:4]), 0644)
+	ioutil.WriteFile(name, []byte("unrelat"), 0644)
+
+	err = client.Transfers.DownloadAll(context.Background(), testDownloadTransfer(int64(len(content))), dir)
+	if err != nil {
+		t.Errorf("TransfersService.DownloadAll returned an error: %v", err)
+	}
+
+	got, _ := ioutil.ReadFile(name)
+	if string(got) != content {
+		t.Errorf("TransfersService.DownloadAll wrote %q, want %q", got, content)
+	}
+	if want := "bytes=4-"; gotRange != want {
+		t.Errorf("TransfersService.DownloadAll resumed with range %q, want %q", gotRange, want)
+	}
+	if _, err := os.Stat(name + ".part"); !os.IsNotExist(err) {
+		t.Errorf("TransfersService.DownloadAll left the partial file behind: %v", err)
+	}
+}
+
+func TestTransfersService_DownloadAll_completePartial(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/transfers/1/files/1/download-url", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("TransfersService.DownloadAll downloaded a complete partial file")
+	})
+
+	dir, err := ioutil.TempDir("", "wt-go-sdk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A previous download completed, but the file was not renamed.
+	name := filepath.Join(dir, "pony.txt")
+	ioutil.WriteFile(name+".part", []byte("yeehaaa"), 0644)
+
+	err = client.Transfers.DownloadAll(context.Background(), testDownloadTransfer(7), dir)
+	if err != nil {
+		t.Errorf("TransfersService.DownloadAll returned an error: %v", err)
+	}
+
+	if got, _ := ioutil.ReadFile(name); string(got) != "yeehaaa" {
+		t.Errorf("TransfersService.DownloadAll wrote %q, want %q", got, "yeehaaa")
+	}
+}
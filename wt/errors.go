@@ -0,0 +1,136 @@
+package wt
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+)
+
+// maxErrorBody is the maximum length of a non-JSON error body kept as the
+// message of an ErrorResponse.
+const maxErrorBody = 512
+
+// AuthError occurs when the API rejects the credentials of a request, with
+// either a 401 or a 403.
+type AuthError struct {
+	*ErrorResponse
+}
+
+// Unwrap returns the underlying *ErrorResponse.
+func (e *AuthError) Unwrap() error { return e.ErrorResponse }
+
+// NotFoundError occurs when a transfer, a board or one of their files does
+// not exist.
+type NotFoundError struct {
+	*ErrorResponse
+}
+
+// Unwrap returns the underlying *ErrorResponse.
+func (e *NotFoundError) Unwrap() error { return e.ErrorResponse }
+
+// RateLimitError occurs when the API rate limit has been hit.
+type RateLimitError struct {
+	*ErrorResponse
+
+	// RetryAfter is the time to wait before the next request, as told by the
+	// API. Zero if unknown.
+	RetryAfter time.Duration
+}
+
+// Unwrap returns the underlying *ErrorResponse.
+func (e *RateLimitError) Unwrap() error { return e.ErrorResponse }
+
+// StorageError reports the rejection of a request by the storage the files
+// are uploaded to, or downloaded from.
+type StorageError struct {
+	Response *http.Response // HTTP response that caused this error
+}
+
+func (e *StorageError) Error() string {
+	return fmt.Sprintf("storage error %v %v: %d",
+		e.Response.Request.Method, redactURL(e.Response.Request.URL), e.Response.StatusCode)
+}
+
+// ChecksumError reports a part received by the storage with a different
+// content than the one sent, as the storage rejected the Content-MD5 of the
+// upload. Parts failing with a ChecksumError are uploaded again.
+type ChecksumError struct {
+	Want string // hex encoded MD5 of the part sent
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("storage rejected part with MD5 %v", e.Want)
+}
+
+// UploadPartError reports the failed upload of a part of a file.
+type UploadPartError struct {
+	FileID     string
+	PartNumber int64
+	Err        error
+}
+
+func (e *UploadPartError) Error() string {
+	return fmt.Sprintf("upload of part %d of file %v: %v", e.PartNumber, e.FileID, e.Err)
+}
+
+// Unwrap returns the error the part upload failed with.
+func (e *UploadPartError) Unwrap() error { return e.Err }
+
+// FileError reports the failed upload of a file. Err is often a *MultiError
+// of *UploadPartError.
+type FileError struct {
+	FileID   string
+	FileName string
+	Err      error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("file %q (%v): %v", e.FileName, e.FileID, e.Err)
+}
+
+// Unwrap returns the error the file upload failed with.
+func (e *FileError) Unwrap() error { return e.Err }
+
+// MultiError gathers the errors of an operation made of several requests,
+// such as the upload of many parts. errors.Is and errors.As look into each of
+// them.
+type MultiError struct {
+	Message string // optional context of the errors
+	Errors  []error
+}
+
+func (e *MultiError) Error() string {
+	buf := new(bytes.Buffer)
+	if e.Message != "" {
+		fmt.Fprintf(buf, "%v:\n", e.Message)
+	}
+	for _, err := range e.Errors {
+		fmt.Fprintf(buf, "%v\n", err.Error())
+	}
+	return buf.String()
+}
+
+// Unwrap returns the gathered errors.
+func (e *MultiError) Unwrap() []error { return e.Errors }
+
+// joinErrors returns a *MultiError of errs with an optional message m.
+func joinErrors(errs []error, m *string) error {
+	merr := &MultiError{Errors: errs}
+	if m != nil {
+		merr.Message = *m
+	}
+	return merr
+}
+
+// truncate shortens s to at most n bytes, without splitting a character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,164 @@
+package wt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestJoinErrors(t *testing.T) {
+	errs := []error{
+		errors.New("error 1"),
+		errors.New("error 2"),
+	}
+
+	err := joinErrors(errs, String("context"))
+
+	want := "context:\nerror 1\nerror 2\n"
+
+	if err.Error() != want {
+		t.Errorf("joinErrors returned %v, want %v", err.Error(), want)
+	}
+}
+
+func TestMultiError_unwrap(t *testing.T) {
+	notFound := &NotFoundError{ErrorResponse: &ErrorResponse{}}
+	err := joinErrors([]error{
+		errors.New("error 1"),
+		&UploadPartError{FileID: "1", PartNumber: 2, Err: notFound},
+	}, nil)
+
+	var partErr *UploadPartError
+	if !errors.As(err, &partErr) {
+		t.Fatalf("errors.As did not find an *UploadPartError in %v", err)
+	}
+	if partErr.FileID != "1" || partErr.PartNumber != 2 {
+		t.Errorf("UploadPartError is %+v, want file 1 part 2", partErr)
+	}
+
+	if !errors.Is(err, notFound) {
+		t.Errorf("errors.Is did not find the *NotFoundError in %v", err)
+	}
+}
+
+func TestCheckResponse_typedErrors(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	client.RetryPolicy = nil
+
+	tests := []struct {
+		code  int
+		check func(error) bool
+	}{
+		{401, func(err error) bool { var e *AuthError; return errors.As(err, &e) }},
+		{403, func(err error) bool { var e *AuthError; return errors.As(err, &e) }},
+		{404, func(err error) bool { var e *NotFoundError; return errors.As(err, &e) }},
+		{429, func(err error) bool {
+			var e *RateLimitError
+			return errors.As(err, &e) && e.RetryAfter == 30*time.Second
+		}},
+		{400, func(err error) bool { _, ok := err.(*ErrorResponse); return ok }},
+	}
+
+	for _, tt := range tests {
+		path := fmt.Sprintf("/transfers/%v", tt.code)
+		mux.HandleFunc(path, func(code int) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "30")
+				w.WriteHeader(code)
+				fmt.Fprint(w, `{"success": false, "message": "Nope"}`)
+			}
+		}(tt.code))
+
+		_, err := client.Transfers.Find(context.Background(), fmt.Sprint(tt.code))
+		if !tt.check(err) {
+			t.Errorf("Find with status %v returned %T", tt.code, err)
+		}
+		testErrorResponse(t, err, "Nope")
+	}
+}
+
+func TestCheckResponse_nonJSON(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	client.RetryPolicy = nil
+
+	mux.HandleFunc("/transfers/1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(502)
+		fmt.Fprint(w, "<html><body>Bad Gateway</body></html>")
+	})
+	mux.HandleFunc("/transfers/2", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(503)
+	})
+
+	_, err := client.Transfers.Find(context.Background(), "1")
+	testErrorResponse(t, err, "<html><body>Bad Gateway</body></html>")
+
+	var errResp *ErrorResponse
+	if errors.As(err, &errResp) && errResp.Response.StatusCode != 502 {
+		t.Errorf("ErrorResponse status is %v, want %v", errResp.Response.StatusCode, 502)
+	}
+
+	_, err = client.Transfers.Find(context.Background(), "2")
+	testErrorResponse(t, err, "Service Unavailable")
+}
+
+func TestUploaderService_upload_partError(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	client.RetryPolicy = nil
+
+	mux.HandleFunc("/transfers/1/files/1/upload-url/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"success": true, "url": "%v/part/1"}`, srvURL)
+	})
+	mux.HandleFunc("/part/1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+	})
+
+	file := &File{
+		ID:        String("1"),
+		Name:      String("pony.txt"),
+		Multipart: &Multipart{PartNumbers: Int64(1), ChunkSize: Int64(5)},
+	}
+	err := client.uploader.upload(context.Background(), &Transfer{ID: String("1")}, newFileTransfer(NewBuffer("pony.txt", []byte("yehaa")), file))
+
+	var partErr *UploadPartError
+	if !errors.As(err, &partErr) || partErr.FileID != "1" || partErr.PartNumber != 1 {
+		t.Fatalf("upload returned %v, want an *UploadPartError of part 1 of file 1", err)
+	}
+
+	var storageErr *StorageError
+	if !errors.As(err, &storageErr) || storageErr.Response.StatusCode != 500 {
+		t.Errorf("upload returned %v, want a *StorageError with status 500", err)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"pony", 4, "pony"},
+		{"ponies", 4, "poni..."},
+		{"poné", 4, "pon..."},
+		{"日本", 4, "日..."},
+	}
+
+	for _, tt := range tests {
+		got := truncate(tt.s, tt.n)
+		if got != tt.want {
+			t.Errorf("truncate(%q, %v) returned %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("truncate(%q, %v) returned invalid UTF-8 %q", tt.s, tt.n, got)
+		}
+	}
+}
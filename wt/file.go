@@ -1,16 +1,21 @@
 package wt
 
 import (
-	"bufio"
-	"bytes"
 	"fmt"
 	"io"
 	"os"
 )
 
-// Uploadable describes a buffer or a file that can be uploaded to WeTransfer.
+// Uploadable describes a buffer, a file or any other source of data that can be
+// uploaded to WeTransfer. Stat must report the exact number of bytes the reader
+// returned by Open yields.
 type Uploadable interface {
+	// Stat returns the name and the size of the uploadable.
 	Stat() (string, int64)
+
+	// Open returns a reader of the content of the uploadable. It is called
+	// once per upload and the reader is closed when the upload is done.
+	Open() (io.ReadCloser, error)
 }
 
 // LocalFile implements the Uploadable interface. It represents
@@ -27,6 +32,11 @@ func (l *LocalFile) Stat() (string, int64) {
 	return l.name, l.size
 }
 
+// Open opens the file on disk for reading.
+func (l *LocalFile) Open() (io.ReadCloser, error) {
+	return os.Open(l.filepath)
+}
+
 // NewLocalFile returns a LocalFile if file exists given the filepath.
 func NewLocalFile(filepath string) (*LocalFile, error) {
 	info, err := os.Stat(filepath)
@@ -52,6 +62,11 @@ func (b *Buffer) Stat() (string, int64) {
 	return b.name, int64(len(b.buffer))
 }
 
+// Open returns a reader of the buffered data.
+func (b *Buffer) Open() (io.ReadCloser, error) {
+	return newBytesReadCloser(b.buffer), nil
+}
+
 // GetBytes returns the b field which represents data.
 func (b *Buffer) GetBytes() []byte {
 	return b.buffer
@@ -72,12 +87,24 @@ type fileItem interface {
 	GetMultipart() *Multipart
 }
 
-// fileTransfer wraps a buffer or file and the response object of
-// upload request to return necessary data including io.Reader for a
-// multipart upload.
+// fileTransfer wraps an uploadable and the response object of upload request to
+// return necessary data including io.Reader for a multipart upload.
 type fileTransfer struct {
 	up   Uploadable
 	file fileItem
+
+	// uploaded reports whether a part has already been uploaded, in which
+	// case it is skipped. Optional.
+	uploaded func(partNum int64) bool
+
+	// onPart is called every time a part has been uploaded. Optional.
+	onPart func(partNum int64)
+
+	// progress reports uploaded parts. Optional.
+	progress *progressTracker
+
+	// digest records what was uploaded, once the upload is done.
+	digest *FileDigest
 }
 
 func (f *fileTransfer) getID() string {
@@ -103,16 +130,31 @@ func (f *fileTransfer) stat() (id string, partNumbers int64, chunkSize int64) {
 	return m.GetID(), m.GetPartNumbers(), m.GetChunkSize()
 }
 
-func (f *fileTransfer) reader() (io.Reader, *os.File, error) {
-	switch v := f.up.(type) {
-	case *LocalFile:
-		local, err := os.Open(v.filepath)
-		return bufio.NewReader(local), local, err
-	case *Buffer:
-		return bytes.NewReader(v.buffer), nil, nil
-	default:
-		return nil, nil, fmt.Errorf("unsupported Uploadable source")
+func (f *fileTransfer) skip(partNum int64) bool {
+	return f.uploaded != nil && f.uploaded(partNum)
+}
+
+// skipsAny reports whether any of the parts of the file is skipped.
+func (f *fileTransfer) skipsAny(partNum int64) bool {
+	for i := int64(1); i <= partNum; i++ {
+		if f.skip(i) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fileTransfer) partDone(partNum int64) {
+	if f.onPart != nil {
+		f.onPart(partNum)
+	}
+}
+
+func (f *fileTransfer) open() (io.ReadCloser, error) {
+	if f == nil || f.up == nil {
+		return nil, fmt.Errorf("no Uploadable source")
 	}
+	return f.up.Open()
 }
 
 func newFileTransfer(up Uploadable, file fileItem) *fileTransfer {
@@ -2,6 +2,8 @@ package wt
 
 import (
 	"fmt"
+	"io/ioutil"
+	"testing"
 )
 
 func ExampleLocalFile() {
@@ -27,3 +29,39 @@ func ExampleNewBuffer() {
 	// pony.txt
 	// 6
 }
+
+func TestBuffer_Open(t *testing.T) {
+	buf := NewBuffer("pony.txt", []byte("yehaaa"))
+
+	rc, err := buf.Open()
+	if err != nil {
+		t.Fatalf("Buffer.Open returned an error: %v", err)
+	}
+	defer rc.Close()
+
+	got, _ := ioutil.ReadAll(rc)
+	if want := "yehaaa"; string(got) != want {
+		t.Errorf("Buffer.Open read %q, want %q", got, want)
+	}
+}
+
+func TestLocalFile_Open(t *testing.T) {
+	tfile := setupTestFile(t, "pony.txt", "yehaaa")
+	defer tfile.Close()
+
+	local, err := NewLocalFile(tfile.Name())
+	if err != nil {
+		t.Fatalf("NewLocalFile returned an error: %v", err)
+	}
+
+	rc, err := local.Open()
+	if err != nil {
+		t.Fatalf("LocalFile.Open returned an error: %v", err)
+	}
+	defer rc.Close()
+
+	got, _ := ioutil.ReadAll(rc)
+	if want := "yehaaa"; string(got) != want {
+		t.Errorf("LocalFile.Open read %q, want %q", got, want)
+	}
+}
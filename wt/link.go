@@ -0,0 +1,135 @@
+package wt
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// normalizeURL checks that u is an absolute http or https URL and returns
+// its normalized form.
+func normalizeURL(u string) (string, error) {
+	p, err := url.Parse(strings.TrimSpace(u))
+	if err != nil {
+		return "", err
+	}
+
+	p.Scheme = strings.ToLower(p.Scheme)
+	if p.Scheme != "http" && p.Scheme != "https" {
+		return "", fmt.Errorf("link %q must be an absolute http or https URL", u)
+	}
+	if p.Hostname() == "" {
+		return "", fmt.Errorf("link %q has no host", u)
+	}
+
+	host, port := strings.ToLower(p.Hostname()), p.Port()
+	if (p.Scheme == "http" && port == "80") || (p.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6
+	}
+	p.Host = host
+
+	if p.Path == "" {
+		p.Path = "/"
+	}
+
+	return p.String(), nil
+}
+
+// linkKey returns the key URLs are compared with to find duplicate links.
+func linkKey(u string) string {
+	if n, err := normalizeURL(u); err == nil {
+		return n
+	}
+	return u
+}
+
+// TitleResolver finds the title of links added to a board without one.
+// ResolveTitle is given the HTTP client the client talks to the API with, so
+// resolvers share its transport.
+type TitleResolver interface {
+	ResolveTitle(ctx context.Context, hc *http.Client, u string) (string, error)
+}
+
+// TitleResolverFunc is a function implementing TitleResolver.
+type TitleResolverFunc func(ctx context.Context, hc *http.Client, u string) (string, error)
+
+// ResolveTitle calls f.
+func (f TitleResolverFunc) ResolveTitle(ctx context.Context, hc *http.Client, u string) (string, error) {
+	return f(ctx, hc, u)
+}
+
+const maxTitleBytes = 64 * 1024
+
+var titleRE = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// HTMLTitleResolver is a TitleResolver fetching the page of a link and
+// reading its <title>. Only the beginning of the page is read.
+var HTMLTitleResolver TitleResolver = TitleResolverFunc(resolveHTMLTitle)
+
+func resolveHTMLTitle(ctx context.Context, hc *http.Client, u string) (string, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("GET %v: %v", u, resp.Status)
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxTitleBytes))
+	if err != nil {
+		return "", err
+	}
+
+	m := titleRE.FindSubmatch(b)
+	if m == nil {
+		return "", fmt.Errorf("no title in %v", u)
+	}
+	return strings.Join(strings.Fields(html.UnescapeString(string(m[1]))), " "), nil
+}
+
+// resolveTitles returns the links with the titles the TitleResolver of the
+// client found for those without one. Links are left without a title if it
+// cannot be resolved.
+func (c *Client) resolveTitles(ctx context.Context, links []*Link) []*Link {
+	if c.TitleResolver == nil {
+		return links
+	}
+
+	resolved := make([]*Link, len(links))
+	for i, link := range links {
+		resolved[i] = link
+		if link.Title != nil || link.URL == nil {
+			continue
+		}
+
+		title, err := c.TitleResolver.ResolveTitle(ctx, c.client, *link.URL)
+		if err != nil || title == "" {
+			c.log(ctx, slog.LevelDebug, "link title not resolved", "url", *link.URL, "error", err)
+			continue
+		}
+		resolved[i] = &Link{URL: link.URL, Title: String(title)}
+	}
+	return resolved
+}
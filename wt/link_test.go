@@ -0,0 +1,160 @@
+package wt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestNewLink(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://wetransfer.com", "https://wetransfer.com/"},
+		{"  HTTPS://WeTransfer.COM:443/About?q=1#top ", "https://wetransfer.com/About?q=1#top"},
+		{"http://example.com:80/a", "http://example.com/a"},
+		{"http://example.com:8080/a", "http://example.com:8080/a"},
+		{"http://[::1]:80", "http://[::1]/"},
+	}
+
+	for _, tt := range tests {
+		link, err := NewLink(tt.url, nil)
+		if err != nil {
+			t.Errorf("NewLink(%q) returned error: %v", tt.url, err)
+			continue
+		}
+		if got := *link.URL; got != tt.want {
+			t.Errorf("NewLink(%q) URL is %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestNewLink_invalid(t *testing.T) {
+	for _, u := range []string{"", "/relative/path", "wetransfer.com", "ftp://example.com", "mailto:pony@example.com", "https://", "http://%zz"} {
+		if _, err := NewLink(u, nil); err == nil {
+			t.Errorf("NewLink(%q) returned no error", u)
+		}
+	}
+}
+
+func TestHTMLTitleResolver(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><TITLE lang="en">
+			Ponies &amp; unicorns
+		</TITLE></head></html>`)
+	})
+	mux.HandleFunc("/untitled", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html></html>`)
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	ctx := context.Background()
+
+	title, err := HTMLTitleResolver.ResolveTitle(ctx, client.client, srvURL+"/page")
+	if err != nil {
+		t.Fatalf("ResolveTitle returned error: %v", err)
+	}
+	if want := "Ponies & unicorns"; title != want {
+		t.Errorf("ResolveTitle returned %q, want %q", title, want)
+	}
+
+	for _, p := range []string{"/untitled", "/missing"} {
+		if _, err := HTMLTitleResolver.ResolveTitle(ctx, client.client, srvURL+p); err == nil {
+			t.Errorf("ResolveTitle(%v) returned no error", p)
+		}
+	}
+}
+
+func TestBoardsService_AddLinks_resolvesTitles(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	client.TitleResolver = TitleResolverFunc(func(ctx context.Context, hc *http.Client, u string) (string, error) {
+		if hc != client.client {
+			t.Error("ResolveTitle was not given the HTTP client of the client")
+		}
+		if u == "https://example.com/" {
+			return "Example", nil
+		}
+		return "", fmt.Errorf("no title")
+	})
+
+	var got []*Link
+	mux.HandleFunc("/boards/1/links", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `[]`)
+	})
+
+	a, _ := NewLink("https://example.com", nil)
+	b, _ := NewLink("https://example.org", nil)
+	c, _ := NewLink("https://example.com", String("Mine"))
+
+	if _, err := client.Boards.AddLinks(context.Background(), &Board{ID: String("1")}, a, b, c); err != nil {
+		t.Fatalf("AddLinks returned error: %v", err)
+	}
+
+	want := []*Link{
+		{URL: String("https://example.com/"), Title: String("Example")},
+		{URL: String("https://example.org/")},
+		{URL: String("https://example.com/"), Title: String("Mine")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AddLinks sent %v, want %v", got, want)
+	}
+
+	// The links given are left untouched.
+	if a.Title != nil {
+		t.Errorf("AddLinks changed the title of a link to %v", *a.Title)
+	}
+}
+
+func TestBoardsService_AddNewLinks(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	var got []*Link
+	mux.HandleFunc("/boards/1/links", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `[{"id": "3", "url": "https://example.org/", "type": "link"}]`)
+	})
+
+	board := &Board{
+		ID: String("1"),
+		Items: []*Item{
+			{ID: String("1"), Type: String("link"), URL: String("https://example.com/")},
+			{ID: String("2"), Type: String("file"), Name: String("https://example.net/")},
+		},
+	}
+
+	a, _ := NewLink("https://EXAMPLE.com", nil)
+	b, _ := NewLink("https://example.org", nil)
+	c, _ := NewLink("https://example.org:443/", nil)
+
+	items, err := client.Boards.AddNewLinks(context.Background(), board, a, b, c)
+	if err != nil {
+		t.Fatalf("AddNewLinks returned error: %v", err)
+	}
+
+	if want := []*Link{{URL: String("https://example.org/")}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("AddNewLinks sent %v, want %v", got, want)
+	}
+	if len(items) != 1 || len(board.Items) != 3 {
+		t.Errorf("AddNewLinks returned %v items and left %v on the board, want 1 and 3", len(items), len(board.Items))
+	}
+
+	// Nothing new, nothing sent.
+	got = nil
+	items, err = client.Boards.AddNewLinks(context.Background(), board, a, b)
+	if err != nil || items != nil || got != nil {
+		t.Errorf("AddNewLinks of existing links returned %v, %v and sent %v", items, err, got)
+	}
+}
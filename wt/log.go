@@ -0,0 +1,190 @@
+package wt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// redacted replaces secrets in logs and error messages.
+	redacted = "REDACTED"
+
+	// maxLogBody is the maximum length of a body logged in debug mode.
+	maxLogBody = 64 * 1024
+)
+
+var (
+	// jwtPattern matches JWT tokens, such as those returned by the API.
+	jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*`)
+
+	// tokenField matches the token field of a JSON body.
+	tokenField = regexp.MustCompile(`("token"\s*:\s*")[^"]*(")`)
+
+	// urlQuery matches the query of URLs, such as presigned upload and
+	// download URLs whose signature is in the query.
+	urlQuery = regexp.MustCompile(`(https?://[^\s"'<>?]+)\?[^\s"'<>]*`)
+)
+
+// redactURL returns u with the values of its query redacted, as the query of
+// presigned URLs holds their signature.
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	if u.RawQuery == "" {
+		return u.String()
+	}
+
+	q := u.Query()
+	for k := range q {
+		q[k] = []string{redacted}
+	}
+
+	r := *u
+	r.RawQuery = q.Encode()
+	return r.String()
+}
+
+// redactURLString is redactURL for a URL given as a string.
+func redactURLString(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		if i := strings.IndexByte(s, '?'); i >= 0 {
+			return s[:i] + "?" + redacted
+		}
+		return s
+	}
+	return redactURL(u)
+}
+
+// redactHeader returns a copy of h with the API key and the JWT token
+// redacted.
+func redactHeader(h http.Header) http.Header {
+	r := h.Clone()
+	if r.Get("x-api-key") != "" {
+		r.Set("x-api-key", redacted)
+	}
+	if r.Get("Authorization") != "" {
+		r.Set("Authorization", "Bearer "+redacted)
+	}
+	return r
+}
+
+// redactBody returns a body to be logged, with JWT tokens and the queries of
+// URLs redacted. Long bodies are truncated.
+func redactBody(b []byte) string {
+	s := truncate(string(b), maxLogBody)
+	s = tokenField.ReplaceAllString(s, "${1}"+redacted+"${2}")
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = urlQuery.ReplaceAllString(s, "${1}?"+redacted)
+	return s
+}
+
+// redactError redacts the URL of the *url.Error returned by an HTTP client.
+// It returns err.
+func redactError(err error) error {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		uerr.URL = redactURLString(uerr.URL)
+	}
+	return err
+}
+
+// requestID returns the ID the API or the storage gave to a request, if any.
+func requestID(resp *http.Response) string {
+	if resp == nil {
+		return ""
+	}
+	for _, h := range []string{"X-Request-Id", "X-Amz-Request-Id"} {
+		if id := resp.Header.Get(h); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// logRequest records a request made to the API or the storage, along with
+// its outcome.
+func (c *Client) logRequest(ctx context.Context, msg string, req *http.Request, resp *http.Response, start time.Time, attempt int, err error, args ...interface{}) {
+	if c.logger == nil {
+		return
+	}
+
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+
+	attrs := []interface{}{
+		"method", req.Method,
+		"path", req.URL.Path,
+		"status", status,
+		"duration", time.Since(start),
+		"attempt", attempt,
+	}
+	if id := requestID(resp); id != "" {
+		attrs = append(attrs, "request_id", id)
+	}
+	attrs = append(attrs, args...)
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+
+	c.log(ctx, slog.LevelDebug, msg, attrs...)
+}
+
+// dumpRequest logs the headers and body of an API request if Debug is set.
+func (c *Client) dumpRequest(ctx context.Context, req *http.Request) {
+	if c.logger == nil || !c.Debug {
+		return
+	}
+
+	var body []byte
+	if req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			body, _ = ioutil.ReadAll(rc)
+			rc.Close()
+		}
+	}
+
+	c.log(ctx, slog.LevelDebug, "api request body",
+		"method", req.Method, "url", redactURL(req.URL),
+		"header", redactHeader(req.Header), "body", redactBody(body))
+}
+
+// dumpResponse logs the headers and body of an API response if Debug is set.
+// The body is read and replaced so that it can still be decoded.
+func (c *Client) dumpResponse(ctx context.Context, resp *http.Response) {
+	if c.logger == nil || !c.Debug {
+		return
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+
+	c.log(ctx, slog.LevelDebug, "api response body",
+		"method", resp.Request.Method, "url", redactURL(resp.Request.URL), "status", resp.StatusCode,
+		"header", redactHeader(resp.Header), "body", redactBody(body))
+}
+
+// errReader is a reader failing with err, or at its end if err is nil.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	return 0, io.EOF
+}
@@ -0,0 +1,147 @@
+package wt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const testPresignedURL = "https://s3.example.com/bucket/key?X-Amz-Credential=AKIA&X-Amz-Signature=deadbeef"
+
+func TestRedactURLString(t *testing.T) {
+	got := redactURLString(testPresignedURL)
+	if strings.Contains(got, "AKIA") || strings.Contains(got, "deadbeef") {
+		t.Errorf("redactURLString returned %v, want the query values redacted", got)
+	}
+	if !strings.HasPrefix(got, "https://s3.example.com/bucket/key?") {
+		t.Errorf("redactURLString returned %v, want the URL kept", got)
+	}
+
+	if got, want := redactURLString("https://wetransfer.com/path"), "https://wetransfer.com/path"; got != want {
+		t.Errorf("redactURLString returned %v, want %v", got, want)
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	jwt := "eyJhbGciOiJub25lIn0.eyJzdWIiOiJ4In0."
+
+	tests := []struct {
+		body   string
+		secret string
+	}{
+		{`{"success": true, "token": "opaque"}`, "opaque"},
+		{`{"message": "bad token ` + jwt + `"}`, jwt},
+		{`{"success": true, "url": "` + testPresignedURL + `"}`, "deadbeef"},
+	}
+
+	for _, tt := range tests {
+		got := redactBody([]byte(tt.body))
+		if strings.Contains(got, tt.secret) || !strings.Contains(got, redacted) {
+			t.Errorf("redactBody(%q) returned %q, want %q redacted", tt.body, got, tt.secret)
+		}
+	}
+}
+
+func TestRedactHeader(t *testing.T) {
+	h := http.Header{}
+	h.Set("x-api-key", "key")
+	h.Set("Authorization", "Bearer jwt")
+	h.Set("User-Agent", "go-wt")
+
+	got := redactHeader(h)
+	if got.Get("x-api-key") != redacted || got.Get("Authorization") != "Bearer "+redacted {
+		t.Errorf("redactHeader returned %v, want the API key and token redacted", got)
+	}
+	if got.Get("User-Agent") != "go-wt" {
+		t.Errorf("redactHeader returned %v, want other headers kept", got)
+	}
+	if h.Get("x-api-key") != "key" {
+		t.Error("redactHeader modified the original header")
+	}
+}
+
+func TestErrors_redacted(t *testing.T) {
+	u, _ := url.Parse(testPresignedURL)
+	resp := &http.Response{
+		StatusCode: http.StatusForbidden,
+		Request:    &http.Request{Method: "PUT", URL: u},
+	}
+
+	errs := []error{
+		&ErrorResponse{Response: resp, Message: "token eyJhbGciOiJub25lIn0.eyJzdWIiOiJ4In0.deadbeef"},
+		&StorageError{Response: resp},
+		redactError(&url.Error{Op: "Put", URL: testPresignedURL, Err: errors.New("connection refused")}),
+	}
+
+	for _, err := range errs {
+		if s := err.Error(); strings.Contains(s, "deadbeef") {
+			t.Errorf("%T.Error() returned %v, want secrets redacted", err, s)
+		}
+	}
+
+	for _, s := range []fmt.Stringer{
+		UploadURL{URL: String(testPresignedURL)},
+		DownloadURL{URL: String(testPresignedURL)},
+	} {
+		if got := s.String(); strings.Contains(got, "deadbeef") {
+			t.Errorf("%T.String() returned %v, want the signature redacted", s, got)
+		}
+	}
+}
+
+func TestClient_logRequests(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	var buf bytes.Buffer
+	client.logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client.Debug = true
+
+	mux.HandleFunc("/transfers/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		fmt.Fprintf(w, `{"id": "1", "url": "%v?X-Amz-Signature=deadbeef"}`, srvURL)
+	})
+	mux.HandleFunc("/part/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Amz-Request-Id", "s3-1")
+	})
+
+	transfer, err := client.Transfers.Find(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("Transfers.Find returned an error: %v", err)
+	}
+	if transfer.GetID() != "1" {
+		t.Errorf("Transfers.Find returned %v, want the body decoded after being logged", transfer)
+	}
+
+	data := []byte("pony")
+	uurl := &UploadURL{URL: String(srvURL + "/part/1?X-Amz-Signature=deadbeef")}
+	if _, err := client.uploader.uploadBytes(context.Background(), uurl, data, nil, 2, "file_id", "f1", "part", 3); err != nil {
+		t.Fatalf("uploadBytes returned an error: %v", err)
+	}
+
+	logs := buf.String()
+	for _, want := range []string{
+		`msg="api request" method=GET path=/transfers/1 status=200`,
+		"attempt=1 request_id=req-1",
+		`msg="part upload" method=PUT path=/part/1 status=200`,
+		"attempt=2 request_id=s3-1 file_id=f1 part=3 bytes=4",
+		`msg="api response body"`,
+		"X-Api-Key:[REDACTED]",
+		"Authorization:[Bearer REDACTED]",
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("logs do not contain %q:\n%v", want, logs)
+		}
+	}
+	for _, secret := range []string{testAPIKey + "]", testJWTAuthToken, "deadbeef"} {
+		if strings.Contains(logs, secret) {
+			t.Errorf("logs contain %q:\n%v", secret, logs)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package wt
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// DuplicatePolicy tells what to do with uploadables sharing a name in a single
+// transfer or board upload.
+type DuplicatePolicy int
+
+const (
+	// DuplicateRename renames an uploadable that has the name of a previous
+	// one, "report.pdf" becoming "report (1).pdf".
+	DuplicateRename DuplicatePolicy = iota
+
+	// DuplicateError rejects uploadables sharing a name with a
+	// *DuplicateNameError before anything is created.
+	DuplicateError
+)
+
+// DuplicateNameError occurs when more than one uploadable has the same name
+// and the client does not rename them.
+type DuplicateNameError struct {
+	Name string
+}
+
+func (e *DuplicateNameError) Error() string {
+	return fmt.Sprintf("more than one uploadable is named %q", e.Name)
+}
+
+// renamed is an uploadable under another name.
+type renamed struct {
+	Uploadable
+	name string
+}
+
+// Stat returns the new name and the size of the uploadable.
+func (r *renamed) Stat() (string, int64) {
+	_, size := r.Uploadable.Stat()
+	return r.name, size
+}
+
+// uniqueNames makes sure every uploadable has a name of its own, renaming the
+// duplicates or returning an error according to policy. Renaming is
+// deterministic so the same uploadables always get the same names.
+func uniqueNames(policy DuplicatePolicy, up []Uploadable) ([]Uploadable, error) {
+	taken := make(map[string]bool, len(up))
+	for _, u := range up {
+		name, _ := u.Stat()
+		taken[name] = true
+	}
+
+	seen := make(map[string]bool, len(up))
+	unique := make([]Uploadable, 0, len(up))
+
+	for _, u := range up {
+		name, _ := u.Stat()
+		if !seen[name] {
+			seen[name] = true
+			unique = append(unique, u)
+			continue
+		}
+
+		if policy == DuplicateError {
+			return nil, &DuplicateNameError{Name: name}
+		}
+
+		ext := filepath.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+		newName := name
+		for i := 1; taken[newName]; i++ {
+			newName = fmt.Sprintf("%v (%d)%v", base, i, ext)
+		}
+		taken[newName] = true
+		seen[newName] = true
+		unique = append(unique, &renamed{Uploadable: u, name: newName})
+	}
+
+	return unique, nil
+}
+
+// matchFiles pairs the files acknowledged by the API with the uploadables they
+// were created from. Files are matched by name, which uniqueNames made
+// unique. Should the API have changed some names, the remaining files are
+// matched by position as long as there are as many files as uploadables. The
+// returned slice has an entry per file, nil for files left unmatched.
+func matchFiles(up []Uploadable, files []fileItem) []*fileTransfer {
+	byName := make(map[string]int, len(up))
+	for i, u := range up {
+		name, _ := u.Stat()
+		byName[name] = i
+	}
+
+	fts := make([]*fileTransfer, len(files))
+	used := make([]bool, len(up))
+
+	for i, f := range files {
+		if j, ok := byName[f.GetName()]; ok && !used[j] {
+			used[j] = true
+			fts[i] = newFileTransfer(up[j], f)
+		}
+	}
+
+	if len(files) != len(up) {
+		return fts
+	}
+
+	for i, f := range files {
+		if fts[i] == nil && !used[i] {
+			used[i] = true
+			fts[i] = newFileTransfer(up[i], f)
+		}
+	}
+
+	return fts
+}
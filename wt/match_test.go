@@ -0,0 +1,170 @@
+package wt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func names(up []Uploadable) []string {
+	var got []string
+	for _, u := range up {
+		name, _ := u.Stat()
+		got = append(got, name)
+	}
+	return got
+}
+
+func TestUniqueNames_rename(t *testing.T) {
+	up := []Uploadable{
+		NewBuffer("report.pdf", []byte("a")),
+		NewBuffer("report.pdf", []byte("bb")),
+		NewBuffer("report (1).pdf", []byte("ccc")),
+		NewBuffer("report.pdf", []byte("dddd")),
+		NewBuffer("notes", []byte("e")),
+		NewBuffer("notes", []byte("f")),
+	}
+
+	got, err := uniqueNames(DuplicateRename, up)
+	if err != nil {
+		t.Fatalf("uniqueNames returned an error: %v", err)
+	}
+
+	want := []string{"report.pdf", "report (2).pdf", "report (1).pdf", "report (3).pdf", "notes", "notes (1)"}
+	if !reflect.DeepEqual(names(got), want) {
+		t.Errorf("uniqueNames returned %v, want %v", names(got), want)
+	}
+
+	if _, size := got[1].Stat(); size != 2 {
+		t.Errorf("Renamed uploadable has size %v, want %v", size, 2)
+	}
+}
+
+func TestUniqueNames_error(t *testing.T) {
+	up := []Uploadable{
+		NewBuffer("report.pdf", []byte("a")),
+		NewBuffer("report.pdf", []byte("b")),
+	}
+
+	_, err := uniqueNames(DuplicateError, up)
+
+	var dupErr *DuplicateNameError
+	if !errors.As(err, &dupErr) || dupErr.Name != "report.pdf" {
+		t.Errorf("uniqueNames returned %v, want a *DuplicateNameError for report.pdf", err)
+	}
+}
+
+func TestMatchFiles(t *testing.T) {
+	a := NewBuffer("a.txt", []byte("a"))
+	b := NewBuffer("b.txt", []byte("b"))
+
+	// Matched by name whatever the order.
+	fts := matchFiles([]Uploadable{a, b}, []fileItem{
+		&File{Name: String("b.txt")},
+		&File{Name: String("a.txt")},
+	})
+	if fts[0].up != b || fts[1].up != a {
+		t.Errorf("matchFiles did not match files by name")
+	}
+
+	// Renamed by the API, matched by position.
+	fts = matchFiles([]Uploadable{a, b}, []fileItem{
+		&File{Name: String("a.txt")},
+		&File{Name: String("b_.txt")},
+	})
+	if fts[0].up != a || fts[1] == nil || fts[1].up != b {
+		t.Errorf("matchFiles did not match a renamed file by position")
+	}
+
+	// Cannot be matched by name nor by position.
+	fts = matchFiles([]Uploadable{a, b}, []fileItem{
+		&File{Name: String("c.txt")},
+	})
+	if fts[0] != nil {
+		t.Errorf("matchFiles matched a file that has no uploadable")
+	}
+}
+
+func TestTransfersService_Create_duplicateNames(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	var mu sync.Mutex
+	got := make(map[string]string)
+
+	mux.HandleFunc("/transfers", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if want := `{"message":null,"files":[{"name":"report.pdf","size":1},{"name":"report (1).pdf","size":1}]}`; string(body) != want+"\n" {
+			t.Errorf("Request body is %s, want %s", body, want)
+		}
+		fmt.Fprint(w, `
+			{
+			  "success": true,
+			  "id": "1",
+			  "files": [
+				{"id": "2", "name": "report (1).pdf", "size": 1, "multipart": {"part_numbers": 1, "chunk_size": 1}},
+				{"id": "1", "name": "report.pdf", "size": 1, "multipart": {"part_numbers": 1, "chunk_size": 1}}
+			  ]
+			}
+		`)
+	})
+	for _, id := range []string{"1", "2"} {
+		mux.HandleFunc(fmt.Sprintf("/transfers/1/files/%v/upload-url/1", id), func(id string) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"success": true, "url": "%v/part/%v"}`, srvURL, id)
+			}
+		}(id))
+		mux.HandleFunc(fmt.Sprintf("/part/%v", id), func(id string) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				mu.Lock()
+				got[id] = string(body)
+				mu.Unlock()
+			}
+		}(id))
+		mux.HandleFunc(fmt.Sprintf("/transfers/1/files/%v/upload-complete", id), func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{}`)
+		})
+	}
+	mux.HandleFunc("/transfers/1/finalize", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"success": true, "id": "1", "state": "done"}`)
+	})
+
+	first := NewBuffer("report.pdf", []byte("a"))
+	second := NewBuffer("report.pdf", []byte("b"))
+
+	if _, err := client.Transfers.Create(context.Background(), nil, first, second); err != nil {
+		t.Fatalf("TransfersService.Create returned an error: %v", err)
+	}
+
+	want := map[string]string{"1": "a", "2": "b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TransfersService.Create uploaded %v, want %v", got, want)
+	}
+}
+
+func TestTransfersService_Create_duplicateNamesError(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	client.DuplicateNames = DuplicateError
+
+	mux.HandleFunc("/transfers", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("TransfersService.Create created a transfer")
+	})
+
+	_, err := client.Transfers.Create(context.Background(), nil,
+		NewBuffer("report.pdf", []byte("a")),
+		NewBuffer("report.pdf", []byte("b")),
+	)
+
+	var dupErr *DuplicateNameError
+	if !errors.As(err, &dupErr) {
+		t.Errorf("TransfersService.Create returned %v, want a *DuplicateNameError", err)
+	}
+}
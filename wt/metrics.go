@@ -0,0 +1,282 @@
+package wt
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics collects the metrics of a client: counters, which only go up, and
+// histograms of observed values. Metrics are identified by name, and their
+// series by labels. Implementations must be safe for concurrent use.
+//
+// The client records the metrics named by the Metric constants. MemoryMetrics
+// is an implementation keeping them in memory, which can be exposed to
+// Prometheus with PrometheusHandler.
+type Metrics interface {
+	// Add adds value to a counter.
+	Add(name string, value float64, labels ...Label)
+
+	// Observe records a value in a histogram.
+	Observe(name string, value float64, labels ...Label)
+}
+
+// Label tells a series of a metric apart from the others.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Metrics recorded by the client.
+const (
+	// MetricAPIRequests counts API requests by method, endpoint and
+	// status. Each attempt of a request is counted.
+	MetricAPIRequests = "wt_api_requests_total"
+
+	// MetricAPIRequestDuration is the histogram of the duration of API
+	// requests in seconds, by method, endpoint and status.
+	MetricAPIRequestDuration = "wt_api_request_duration_seconds"
+
+	// MetricPartUploads counts part uploads to the storage by status. Each
+	// attempt of an upload is counted.
+	MetricPartUploads = "wt_part_uploads_total"
+
+	// MetricPartUploadBytes counts the bytes of the parts uploaded.
+	MetricPartUploadBytes = "wt_part_upload_bytes_total"
+
+	// MetricPartUploadDuration is the histogram of the duration of part
+	// uploads in seconds, by status.
+	MetricPartUploadDuration = "wt_part_upload_duration_seconds"
+
+	// MetricDownloadBytes counts the bytes of the files downloaded.
+	MetricDownloadBytes = "wt_download_bytes_total"
+
+	// MetricRetries counts retries by operation: api_request, part_upload
+	// or download.
+	MetricRetries = "wt_retries_total"
+
+	// MetricTransfersCreated counts the transfers created.
+	MetricTransfersCreated = "wt_transfers_created_total"
+
+	// MetricTransfersFinalized counts the transfers finalized.
+	MetricTransfersFinalized = "wt_transfers_finalized_total"
+)
+
+// metricHelp describes the metrics recorded by the client.
+var metricHelp = map[string]string{
+	MetricAPIRequests:        "API requests by method, endpoint and status.",
+	MetricAPIRequestDuration: "Duration of API requests in seconds.",
+	MetricPartUploads:        "Part uploads to the storage by status.",
+	MetricPartUploadBytes:    "Bytes of the parts uploaded to the storage.",
+	MetricPartUploadDuration: "Duration of part uploads in seconds.",
+	MetricDownloadBytes:      "Bytes of the files downloaded from the storage.",
+	MetricRetries:            "Retries by operation.",
+	MetricTransfersCreated:   "Transfers created.",
+	MetricTransfersFinalized: "Transfers finalized.",
+}
+
+// endpointSegments are the literal segments of the paths of the API. Other
+// segments are IDs and part numbers.
+var endpointSegments = map[string]bool{
+	"authorize":       true,
+	"boards":          true,
+	"download-url":    true,
+	"files":           true,
+	"finalize":        true,
+	"items":           true,
+	"links":           true,
+	"transfers":       true,
+	"upload-complete": true,
+	"upload-url":      true,
+}
+
+// count adds value to a counter of the metrics of the client, if any.
+func (c *Client) count(name string, value float64, labels ...Label) {
+	if c.Metrics != nil {
+		c.Metrics.Add(name, value, labels...)
+	}
+}
+
+// observe records a value in a histogram of the metrics of the client, if any.
+func (c *Client) observe(name string, value float64, labels ...Label) {
+	if c.Metrics != nil {
+		c.Metrics.Observe(name, value, labels...)
+	}
+}
+
+// retried counts a retry of an operation.
+func (c *Client) retried(operation string) {
+	c.count(MetricRetries, 1, Label{"operation", operation})
+}
+
+// endpoint returns the path of an API request relative to the base URL, with
+// IDs replaced so that requests to the same endpoint share a series.
+func (c *Client) endpoint(u *url.URL) string {
+	path := strings.TrimPrefix(u.Path, c.BaseURL.Path)
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, s := range segments {
+		if !endpointSegments[s] {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// statusLabel returns the status label of a request: the status code of its
+// response, or "error" if none was received.
+func statusLabel(resp *http.Response) Label {
+	if resp == nil {
+		return Label{"status", "error"}
+	}
+	return Label{"status", strconv.Itoa(resp.StatusCode)}
+}
+
+// DefaultBuckets returns the upper bounds, in seconds, of the histogram
+// buckets of new MemoryMetrics.
+func DefaultBuckets() []float64 {
+	return []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120}
+}
+
+// MemoryMetrics is a Metrics keeping metrics in memory. The zero value is
+// ready to use, with the DefaultBuckets.
+type MemoryMetrics struct {
+	// Buckets are the increasing upper bounds of the buckets of histograms.
+	// If nil, the DefaultBuckets are used. They must not be changed once a
+	// value has been observed.
+	Buckets []float64
+
+	mu         sync.Mutex
+	bounds     []float64                   // buckets in use, set on first use
+	counters   map[string]*counterSeries   // by seriesKey
+	histograms map[string]*histogramSeries // by seriesKey
+}
+
+type counterSeries struct {
+	name   string
+	labels []Label
+	value  float64
+}
+
+type histogramSeries struct {
+	name   string
+	labels []Label
+	counts []uint64 // counts[i] is the number of values in bucket i only
+	count  uint64
+	sum    float64
+}
+
+// NewMemoryMetrics returns an empty MemoryMetrics with the DefaultBuckets.
+func NewMemoryMetrics() *MemoryMetrics {
+	return &MemoryMetrics{Buckets: DefaultBuckets()}
+}
+
+// init prepares m for its first use. It must be called with m.mu held.
+func (m *MemoryMetrics) init() {
+	if m.bounds != nil {
+		return
+	}
+
+	m.bounds = m.Buckets
+	if m.bounds == nil {
+		m.bounds = DefaultBuckets()
+	}
+	m.counters = make(map[string]*counterSeries)
+	m.histograms = make(map[string]*histogramSeries)
+}
+
+// Add adds value to a counter.
+func (m *MemoryMetrics) Add(name string, value float64, labels ...Label) {
+	labels = sortLabels(labels)
+	key := seriesKey(name, labels)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	s, ok := m.counters[key]
+	if !ok {
+		s = &counterSeries{name: name, labels: labels}
+		m.counters[key] = s
+	}
+	s.value += value
+}
+
+// Observe records a value in a histogram.
+func (m *MemoryMetrics) Observe(name string, value float64, labels ...Label) {
+	labels = sortLabels(labels)
+	key := seriesKey(name, labels)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.init()
+
+	s, ok := m.histograms[key]
+	if !ok {
+		s = &histogramSeries{name: name, labels: labels, counts: make([]uint64, len(m.bounds))}
+		m.histograms[key] = s
+	}
+
+	if i := sort.SearchFloat64s(m.bounds, value); i < len(s.counts) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += value
+}
+
+// Counter returns the value of a counter, or 0 if it was never added to.
+func (m *MemoryMetrics) Counter(name string, labels ...Label) float64 {
+	key := seriesKey(name, sortLabels(labels))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.counters[key]; ok {
+		return s.value
+	}
+	return 0
+}
+
+// Histogram returns the number and the sum of the values observed in a
+// histogram.
+func (m *MemoryMetrics) Histogram(name string, labels ...Label) (count uint64, sum float64) {
+	key := seriesKey(name, sortLabels(labels))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.histograms[key]; ok {
+		return s.count, s.sum
+	}
+	return 0, 0
+}
+
+// sortLabels returns a copy of labels sorted by name.
+func sortLabels(labels []Label) []Label {
+	sorted := make([]Label, len(labels))
+	copy(sorted, labels)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// seriesKey identifies the series of a metric with sorted labels.
+func seriesKey(name string, labels []Label) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for _, l := range labels {
+		b.WriteByte(0)
+		b.WriteString(l.Name)
+		b.WriteByte(0)
+		b.WriteString(l.Value)
+	}
+	return b.String()
+}
+
+// seconds returns the time elapsed since start in seconds.
+func seconds(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
@@ -0,0 +1,148 @@
+package wt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMemoryMetrics(t *testing.T) {
+	m := NewMemoryMetrics()
+	m.Buckets = []float64{1, 5}
+
+	m.Add("c", 1, Label{"a", "1"}, Label{"b", "2"})
+	m.Add("c", 2, Label{"b", "2"}, Label{"a", "1"})
+	m.Add("c", 4, Label{"a", "2"}, Label{"b", "2"})
+
+	if got := m.Counter("c", Label{"b", "2"}, Label{"a", "1"}); got != 3 {
+		t.Errorf("Counter returned %v, want %v", got, 3)
+	}
+	if got := m.Counter("c"); got != 0 {
+		t.Errorf("Counter without labels returned %v, want %v", got, 0)
+	}
+
+	for _, v := range []float64{0.5, 1, 3, 10} {
+		m.Observe("h", v)
+	}
+
+	count, sum := m.Histogram("h")
+	if count != 4 || sum != 14.5 {
+		t.Errorf("Histogram returned %v, %v, want %v, %v", count, sum, 4, 14.5)
+	}
+
+	want := []uint64{2, 1}
+	got := m.histograms[seriesKey("h", nil)].counts
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Histogram bucket counts are %v, want %v", got, want)
+	}
+}
+
+func TestMemoryMetrics_zero(t *testing.T) {
+	m := &MemoryMetrics{}
+
+	m.Add("c", 1)
+	m.Observe("h", 0.5)
+
+	if got := m.Counter("c"); got != 1 {
+		t.Errorf("Counter returned %v, want %v", got, 1)
+	}
+	if count, _ := m.Histogram("h"); count != 1 {
+		t.Errorf("Histogram count is %v, want %v", count, 1)
+	}
+	if got, want := len(m.histograms[seriesKey("h", nil)].counts), len(DefaultBuckets()); got != want {
+		t.Errorf("Histogram has %v buckets, want %v", got, want)
+	}
+}
+
+func TestClient_endpoint(t *testing.T) {
+	client, _ := NewClient("key")
+	client.BaseURL, _ = url.Parse("https://example.com/v2/")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/v2/authorize", "authorize"},
+		{"/v2/transfers", "transfers"},
+		{"/v2/transfers/abc/finalize", "transfers/{id}/finalize"},
+		{"/v2/transfers/abc/files/def/upload-url/3", "transfers/{id}/files/{id}/upload-url/{id}"},
+		{"/v2/boards/abc/files/def/upload-url/3/ghi", "boards/{id}/files/{id}/upload-url/{id}/{id}"},
+		{"/v2/boards/abc/items/def", "boards/{id}/items/{id}"},
+	}
+
+	for _, tt := range tests {
+		got := client.endpoint(&url.URL{Path: tt.path})
+		if got != tt.want {
+			t.Errorf("endpoint(%q) returned %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestClient_metrics(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	m := NewMemoryMetrics()
+	client.Metrics = m
+	client.RetryPolicy = &RetryPolicy{MaxAttempts: 2}
+
+	mux.HandleFunc("/transfers", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": "1", "state": "uploading", "files": [
+			{"id": "1", "name": "pony.txt", "size": 5, "multipart": {"part_numbers": 1, "chunk_size": 5}}
+		]}`)
+	})
+	mux.HandleFunc("/transfers/1/files/1/upload-url/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"success": true, "url": "%v/part/1"}`, srvURL)
+	})
+
+	var puts int32
+	mux.HandleFunc("/part/1", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&puts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	mux.HandleFunc("/transfers/1/files/1/upload-complete", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": "1"}`)
+	})
+	mux.HandleFunc("/transfers/1/finalize", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": "1", "state": "processing"}`)
+	})
+
+	_, err := client.Transfers.Create(context.Background(), nil, NewBuffer("pony.txt", []byte("yehaa")))
+	if err != nil {
+		t.Fatalf("Transfers.Create returned error: %v", err)
+	}
+
+	counters := []struct {
+		name   string
+		labels []Label
+		want   float64
+	}{
+		{MetricAPIRequests, []Label{{"method", "POST"}, {"endpoint", "transfers"}, {"status", "200"}}, 1},
+		{MetricAPIRequests, []Label{{"method", "GET"}, {"endpoint", "transfers/{id}/files/{id}/upload-url/{id}"}, {"status", "200"}}, 1},
+		{MetricAPIRequests, []Label{{"method", "PUT"}, {"endpoint", "transfers/{id}/finalize"}, {"status", "200"}}, 1},
+		{MetricPartUploads, []Label{{"status", "503"}}, 1},
+		{MetricPartUploads, []Label{{"status", "200"}}, 1},
+		{MetricPartUploadBytes, nil, 5},
+		{MetricRetries, []Label{{"operation", "part_upload"}}, 1},
+		{MetricTransfersCreated, nil, 1},
+		{MetricTransfersFinalized, nil, 1},
+	}
+
+	for _, c := range counters {
+		if got := m.Counter(c.name, c.labels...); got != c.want {
+			t.Errorf("Counter(%v, %v) returned %v, want %v", c.name, c.labels, got, c.want)
+		}
+	}
+
+	if count, _ := m.Histogram(MetricAPIRequestDuration,
+		Label{"method", "PUT"}, Label{"endpoint", "transfers/{id}/finalize"}, Label{"status", "200"}); count != 1 {
+		t.Errorf("Histogram(%v) count is %v, want %v", MetricAPIRequestDuration, count, 1)
+	}
+	if count, _ := m.Histogram(MetricPartUploadDuration, Label{"status", "200"}); count != 1 {
+		t.Errorf("Histogram(%v) count is %v, want %v", MetricPartUploadDuration, count, 1)
+	}
+}
@@ -0,0 +1,169 @@
+package wt
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Option configures a Client created by NewClient or NewAuthorizedClient.
+type Option func(*Client) error
+
+// WithHTTPClient sets the HTTP client used to talk to the API. Unless
+// WithStorageHTTPClient is used too, it is also used to talk to the storage.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) error {
+		if hc == nil {
+			return fmt.Errorf("HTTP client must not be nil")
+		}
+		c.client = hc
+		return nil
+	}
+}
+
+// WithStorageHTTPClient sets the HTTP client used to upload files to, and
+// download files from, the object storage behind the API.
+func WithStorageHTTPClient(hc *http.Client) Option {
+	return func(c *Client) error {
+		if hc == nil {
+			return fmt.Errorf("storage HTTP client must not be nil")
+		}
+		c.storage = hc
+		return nil
+	}
+}
+
+// WithBaseURL sets the base URL of API requests. A trailing slash is added if
+// missing.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) error {
+		if !strings.HasSuffix(baseURL, "/") {
+			baseURL += "/"
+		}
+		u, err := url.Parse(baseURL)
+		if err != nil {
+			return err
+		}
+		if !u.IsAbs() {
+			return fmt.Errorf("base URL %q must be absolute", baseURL)
+		}
+		c.BaseURL = u
+		return nil
+	}
+}
+
+// WithUserAgent sets the user agent used when communicating with the API.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) error {
+		c.UserAgent = ua
+		return nil
+	}
+}
+
+// WithLogger sets the logger of the client.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *Client) error {
+		c.logger = l
+		return nil
+	}
+}
+
+// WithDebug sets whether the headers and bodies of API requests and responses
+// are logged too, with secrets redacted.
+func WithDebug(enabled bool) Option {
+	return func(c *Client) error {
+		c.Debug = enabled
+		return nil
+	}
+}
+
+// WithTracer sets the tracer of the client.
+func WithTracer(t Tracer) Option {
+	return func(c *Client) error {
+		c.Tracer = t
+		return nil
+	}
+}
+
+// WithMetrics sets the metrics collector of the client.
+func WithMetrics(m Metrics) Option {
+	return func(c *Client) error {
+		c.Metrics = m
+		return nil
+	}
+}
+
+// WithRetryPolicy sets the retry policy of the client. A nil policy disables
+// retries.
+func WithRetryPolicy(p *RetryPolicy) Option {
+	return func(c *Client) error {
+		c.RetryPolicy = p
+		return nil
+	}
+}
+
+// WithConcurrency sets the maximum number of parts uploaded at the same time
+// across all files, and for a single file.
+func WithConcurrency(total, perFile int) Option {
+	return func(c *Client) error {
+		if total < 1 || perFile < 1 {
+			return fmt.Errorf("concurrency must be at least 1")
+		}
+		c.Concurrency = total
+		c.FileConcurrency = perFile
+		return nil
+	}
+}
+
+// WithTokenSource sets the source of the JWT tokens of the client.
+func WithTokenSource(ts TokenSource) Option {
+	return func(c *Client) error {
+		c.TokenSource = ts
+		return nil
+	}
+}
+
+// WithProgress sets the function receiving the progress of uploads.
+func WithProgress(fn ProgressFunc) Option {
+	return func(c *Client) error {
+		c.Progress = fn
+		return nil
+	}
+}
+
+// WithDuplicateNames sets what to do with uploadables sharing a name.
+func WithDuplicateNames(p DuplicatePolicy) Option {
+	return func(c *Client) error {
+		c.DuplicateNames = p
+		return nil
+	}
+}
+
+// WithLimits sets the limits transfers are validated against. Nil disables
+// the limits.
+func WithLimits(l *Limits) Option {
+	return func(c *Client) error {
+		c.Limits = l
+		return nil
+	}
+}
+
+// WithTitleResolver sets the resolver of the titles of links added to a board
+// without one, such as HTMLTitleResolver.
+func WithTitleResolver(r TitleResolver) Option {
+	return func(c *Client) error {
+		c.TitleResolver = r
+		return nil
+	}
+}
+
+// WithCleanup sets whether uploads abandoned because of an error or a canceled
+// context remove the transfer or board items they created.
+func WithCleanup(enabled bool) Option {
+	return func(c *Client) error {
+		c.Cleanup = enabled
+		return nil
+	}
+}
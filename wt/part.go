@@ -0,0 +1,182 @@
+package wt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// partReader cuts the content of an uploadable into the parts of a multipart
+// upload. Part n starts at offset (n-1)*chunkSize, and all parts but the last
+// one are exactly chunkSize bytes long.
+//
+// Content held in memory is sliced, and content readable at an offset, such
+// as a regular file, is read with ReadAt. Parts of both can be read in any
+// order and concurrently. Other content is read sequentially.
+type partReader struct {
+	size      int64
+	chunkSize int64
+
+	data []byte      // content held in memory, if any
+	ra   io.ReaderAt // content readable at an offset, if any
+	r    io.Reader   // content readable in order otherwise
+	off  int64       // offset of r
+}
+
+// newPartReader returns a partReader of the size bytes of r.
+func newPartReader(r io.Reader, size, chunkSize int64) *partReader {
+	p := &partReader{size: size, chunkSize: chunkSize}
+
+	switch {
+	case bytesOf(r) != nil:
+		p.data = bytesOf(r)
+	case readerAt(r) != nil:
+		p.ra = readerAt(r)
+	default:
+		p.r = r
+	}
+
+	return p
+}
+
+// inMemory reports whether parts are sliced from memory, in which case read
+// needs no buffer.
+func (p *partReader) inMemory() bool {
+	return p.data != nil
+}
+
+// random reports whether parts can be read in any order, concurrently.
+func (p *partReader) random() bool {
+	return p.data != nil || p.ra != nil
+}
+
+// length returns the expected length of part n.
+func (p *partReader) length(n int64) int64 {
+	l := p.size - (n-1)*p.chunkSize
+	if l > p.chunkSize {
+		l = p.chunkSize
+	}
+	if l < 0 {
+		l = 0
+	}
+	return l
+}
+
+// read returns part n, read into buf unless the content is in memory. buf must
+// be at least chunkSize bytes long. Unless random reports true, parts must be
+// read in increasing order by a single goroutine; those in between are
+// skipped. The part may be short if the content is, see check.
+func (p *partReader) read(n int64, buf []byte) ([]byte, error) {
+	off := (n - 1) * p.chunkSize
+	l := p.length(n)
+
+	switch {
+	case p.data != nil:
+		if off >= int64(len(p.data)) {
+			return p.data[:0], nil
+		}
+		end := off + l
+		if end > int64(len(p.data)) {
+			end = int64(len(p.data))
+		}
+		return p.data[off:end], nil
+
+	case p.ra != nil:
+		k, err := p.ra.ReadAt(buf[:l], off)
+		if err == io.EOF {
+			err = nil
+		}
+		return buf[:k], err
+	}
+
+	if off < p.off {
+		return nil, fmt.Errorf("part %d read out of order", n)
+	}
+	if off > p.off {
+		k, err := io.CopyN(ioutil.Discard, p.r, off-p.off)
+		p.off += k
+		if err == io.EOF {
+			return buf[:0], nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Readers such as pipes return what they have, fill the part.
+	k, err := io.ReadFull(p.r, buf[:l])
+	p.off += int64(k)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return buf[:k], err
+}
+
+// check returns an error if data is not as long as part n must be.
+func (p *partReader) check(n int64, data []byte) error {
+	if want := p.length(n); int64(len(data)) != want {
+		return fmt.Errorf("part %d is %d bytes long, want %d", n, len(data), want)
+	}
+	return nil
+}
+
+// checkEnd returns an error if content is left past the size of a reader read
+// in order, once its last part has been read. Other readers are only read up
+// to their size.
+func (p *partReader) checkEnd() error {
+	if p.random() {
+		return nil
+	}
+
+	var b [1]byte
+	n, err := io.ReadFull(p.r, b[:])
+	if n > 0 {
+		return fmt.Errorf("content is longer than %d bytes", p.size)
+	}
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// bytesReadCloser is the reader of content held in memory, which parts are
+// sliced from instead of being copied.
+type bytesReadCloser struct {
+	*bytes.Reader
+	b []byte
+}
+
+func newBytesReadCloser(b []byte) *bytesReadCloser {
+	return &bytesReadCloser{Reader: bytes.NewReader(b), b: b}
+}
+
+// Close does nothing.
+func (r *bytesReadCloser) Close() error {
+	return nil
+}
+
+// bytesOf returns the content of r if it is held in memory, or nil.
+func bytesOf(r io.Reader) []byte {
+	if b, ok := r.(*bytesReadCloser); ok && b.b != nil {
+		return b.b
+	}
+	return nil
+}
+
+// readerAt returns r as an io.ReaderAt if it can be read at any offset, or
+// nil. Files such as pipes and devices cannot.
+func readerAt(r io.Reader) io.ReaderAt {
+	ra, ok := r.(io.ReaderAt)
+	if !ok {
+		return nil
+	}
+	if f, ok := r.(*os.File); ok {
+		info, err := f.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+	}
+	return ra
+}
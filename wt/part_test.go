@@ -0,0 +1,91 @@
+package wt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestPartReader(t *testing.T) {
+	const content = "0123456789"
+
+	path := filepath.Join(t.TempDir(), "content")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		name     string
+		parts    *partReader
+		inMemory bool
+		random   bool
+	}{
+		{"buffer", newPartReader(newBytesReadCloser([]byte(content)), 10, 4), true, true},
+		{"file", newPartReader(f, 10, 4), false, true},
+		{"stream", newPartReader(iotest.OneByteReader(strings.NewReader(content)), 10, 4), false, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.parts.inMemory(); got != tt.inMemory {
+			t.Errorf("%v: inMemory returned %v, want %v", tt.name, got, tt.inMemory)
+		}
+		if got := tt.parts.random(); got != tt.random {
+			t.Errorf("%v: random returned %v, want %v", tt.name, got, tt.random)
+		}
+
+		// Part 2 is skipped, and random parts are read backwards.
+		want := map[int64]string{1: "0123", 3: "89"}
+		order := []int64{1, 3}
+		if tt.random {
+			order = []int64{3, 1}
+		}
+
+		for _, n := range order {
+			data, err := tt.parts.read(n, make([]byte, 4))
+			if err != nil {
+				t.Errorf("%v: read(%d) returned error: %v", tt.name, n, err)
+				continue
+			}
+			if string(data) != want[n] {
+				t.Errorf("%v: read(%d) returned %q, want %q", tt.name, n, data, want[n])
+			}
+			if err := tt.parts.check(n, data); err != nil {
+				t.Errorf("%v: check(%d) returned error: %v", tt.name, n, err)
+			}
+		}
+	}
+}
+
+func TestPartReader_short(t *testing.T) {
+	// The content is shorter than announced.
+	parts := newPartReader(strings.NewReader("012345"), 10, 4)
+
+	data, err := parts.read(2, make([]byte, 4))
+	if err != nil {
+		t.Fatalf("read returned error: %v", err)
+	}
+	if err := parts.check(2, data); err == nil || !strings.Contains(err.Error(), "2 bytes long, want 4") {
+		t.Errorf("check returned %v, want an error about the length", err)
+	}
+}
+
+func TestPartReader_pipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	if parts := newPartReader(r, 10, 4); parts.random() {
+		t.Error("parts of a pipe can be read at random")
+	}
+}
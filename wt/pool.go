@@ -0,0 +1,47 @@
+package wt
+
+import "context"
+
+const (
+	defaultConcurrency     = 8
+	defaultFileConcurrency = 4
+)
+
+// bufferPool is a fixed set of reusable chunk buffers. The number of buffers
+// bounds the number of parts held in memory, and therefore uploaded, at the
+// same time.
+type bufferPool struct {
+	bufs chan []byte
+}
+
+// newBufferPool returns a pool of n buffers. Buffers are allocated lazily the
+// first time they are handed out.
+func newBufferPool(n int) *bufferPool {
+	if n < 1 {
+		n = 1
+	}
+	p := &bufferPool{bufs: make(chan []byte, n)}
+	for i := 0; i < n; i++ {
+		p.bufs <- nil
+	}
+	return p
+}
+
+// get blocks until a buffer is available and returns it with a length of
+// size. It returns ctx.Err() if ctx is done before a buffer is available.
+func (p *bufferPool) get(ctx context.Context, size int64) ([]byte, error) {
+	select {
+	case b := <-p.bufs:
+		if int64(cap(b)) < size {
+			b = make([]byte, size)
+		}
+		return b[:size], nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// put returns a buffer obtained from get to the pool.
+func (p *bufferPool) put(b []byte) {
+	p.bufs <- b
+}
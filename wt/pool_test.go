@@ -0,0 +1,45 @@
+package wt
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBufferPool_get(t *testing.T) {
+	p := newBufferPool(1)
+
+	b, err := p.get(context.Background(), 4)
+	if err != nil {
+		t.Fatalf("bufferPool.get returned an error: %v", err)
+	}
+	if len(b) != 4 {
+		t.Errorf("bufferPool.get returned %v bytes, want %v", len(b), 4)
+	}
+	b[0] = 'x'
+	p.put(b)
+
+	// A smaller buffer reuses the memory of the previous one.
+	b, _ = p.get(context.Background(), 2)
+	if len(b) != 2 || b[0] != 'x' {
+		t.Errorf("bufferPool.get did not reuse the returned buffer")
+	}
+	p.put(b)
+
+	// A larger buffer is reallocated.
+	b, _ = p.get(context.Background(), 8)
+	if len(b) != 8 {
+		t.Errorf("bufferPool.get returned %v bytes, want %v", len(b), 8)
+	}
+}
+
+func TestBufferPool_get_canceled(t *testing.T) {
+	p := newBufferPool(1)
+	p.get(context.Background(), 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := p.get(ctx, 1); err != context.Canceled {
+		t.Errorf("bufferPool.get returned %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,172 @@
+package wt
+
+import "sync"
+
+// Phase is a step of the upload ceremony of a transfer or of board files.
+type Phase string
+
+// Phases reported to a ProgressFunc, in order.
+const (
+	PhaseCreate   Phase = "create"   // the transfer or the board files are being created
+	PhaseUpload   Phase = "upload"   // the files are uploaded, each marked as complete once its parts are
+	PhaseFinalize Phase = "finalize" // the transfer is being finalized
+	PhaseDone     Phase = "done"     // everything went through
+)
+
+// Progress describes the state of an upload. It is reported once per phase
+// change, and once per uploaded part during PhaseUpload.
+type Progress struct {
+	Phase Phase
+
+	// ID of the transfer or the board. Blank during PhaseCreate.
+	ID string
+
+	// The file a part was uploaded for. Blank on phase changes.
+	FileID   string
+	FileName string
+
+	// The part that was uploaded and its size in bytes. Zero on phase changes.
+	PartNumber int64
+	PartBytes  int64
+
+	// Parts uploaded out of the parts of the file.
+	PartsDone   int64
+	PartNumbers int64
+
+	// Bytes uploaded out of the size of the file.
+	FileBytes int64
+	FileSize  int64
+
+	// Bytes uploaded out of the size of all files.
+	Bytes     int64
+	TotalSize int64
+}
+
+// ProgressFunc receives the progress of uploads. Calls are serialized, so the
+// function does not need to be safe for concurrent use, but it blocks the
+// upload while it runs.
+type ProgressFunc func(Progress)
+
+// ProgressChan returns a ProgressFunc sending progress to ch. The channel must
+// be drained for the upload to move on.
+func ProgressChan(ch chan<- Progress) ProgressFunc {
+	return func(p Progress) {
+		ch <- p
+	}
+}
+
+// progressTracker accumulates the progress of an upload and reports it to a
+// ProgressFunc. A tracker with a nil ProgressFunc does nothing.
+type progressTracker struct {
+	mu    sync.Mutex
+	fn    ProgressFunc
+	id    string
+	phase Phase
+	bytes int64
+	total int64
+	files map[*fileTransfer]*fileProgress
+}
+
+type fileProgress struct {
+	parts int64
+	bytes int64
+}
+
+// newProgressTracker returns a tracker of the upload of the given uploadables.
+func newProgressTracker(fn ProgressFunc, up ...Uploadable) *progressTracker {
+	p := &progressTracker{
+		fn:    fn,
+		files: make(map[*fileTransfer]*fileProgress),
+	}
+	for _, u := range up {
+		_, size := u.Stat()
+		p.total += size
+	}
+	return p
+}
+
+// add registers the upload of a file so its parts are tracked.
+func (p *progressTracker) add(ft *fileTransfer) {
+	if p == nil || p.fn == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.files[ft] = &fileProgress{}
+	ft.progress = p
+}
+
+// setPhase reports a phase change. The ID of the transfer or board is updated
+// unless blank.
+func (p *progressTracker) setPhase(phase Phase, id string) {
+	if p == nil || p.fn == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.phase = phase
+	if id != "" {
+		p.id = id
+	}
+
+	p.fn(Progress{
+		Phase:     phase,
+		ID:        p.id,
+		Bytes:     p.bytes,
+		TotalSize: p.total,
+	})
+}
+
+// part reports an uploaded part of a file.
+func (p *progressTracker) part(ft *fileTransfer, partNum, n int64) {
+	p.record(ft, partNum, n, true)
+}
+
+// skip accounts for a part of a file that was uploaded earlier, without
+// reporting it.
+func (p *progressTracker) skip(ft *fileTransfer, partNum, n int64) {
+	p.record(ft, partNum, n, false)
+}
+
+func (p *progressTracker) record(ft *fileTransfer, partNum, n int64, report bool) {
+	if p == nil || p.fn == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fp, ok := p.files[ft]
+	if !ok {
+		return
+	}
+	fp.parts++
+	fp.bytes += n
+	p.bytes += n
+
+	if !report {
+		return
+	}
+
+	_, size := ft.up.Stat()
+	_, partNumbers, _ := ft.stat()
+
+	p.fn(Progress{
+		Phase:       p.phase,
+		ID:          p.id,
+		FileID:      ft.getID(),
+		FileName:    ft.getName(),
+		PartNumber:  partNum,
+		PartBytes:   n,
+		PartsDone:   fp.parts,
+		PartNumbers: partNumbers,
+		FileBytes:   fp.bytes,
+		FileSize:    size,
+		Bytes:       p.bytes,
+		TotalSize:   p.total,
+	})
+}
@@ -0,0 +1,118 @@
+package wt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestTransfersService_Create_progress(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/transfers", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `
+			{
+			  "success" : true,
+			  "id" : "1",
+			  "state" : "uploading",
+			  "files" : [
+				{
+				  "multipart" : {
+					"part_numbers" : 2,
+					"chunk_size" : 5
+				  },
+				  "size" : 10,
+				  "type" : "file",
+				  "name" : "pony.txt",
+				  "id" : "1"
+				}
+			  ]
+			}
+		`)
+	})
+	for i := 1; i <= 2; i++ {
+		mux.HandleFunc(fmt.Sprintf("/transfers/1/files/1/upload-url/%v", i), func(i int) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"success": true, "url": "%v/part/%v"}`, srvURL, i)
+			}
+		}(i))
+		mux.HandleFunc(fmt.Sprintf("/part/%v", i), func(w http.ResponseWriter, r *http.Request) {})
+	}
+	mux.HandleFunc("/transfers/1/files/1/upload-complete", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": "1", "retries": 0, "name": "pony.txt", "size": 10, "chunk_size": 5}`)
+	})
+	mux.HandleFunc("/transfers/1/finalize", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"success": true, "id": "1", "state": "done"}`)
+	})
+
+	var events []Progress
+	client.Progress = func(p Progress) {
+		events = append(events, p)
+	}
+
+	buf := NewBuffer("pony.txt", []byte("yehaayehaa"))
+	if _, err := client.Transfers.Create(context.Background(), nil, buf); err != nil {
+		t.Fatalf("TransfersService.Create returned an error: %v", err)
+	}
+
+	var phases []Phase
+	var parts int
+	for _, e := range events {
+		if e.PartNumber == 0 {
+			phases = append(phases, e.Phase)
+			continue
+		}
+		parts++
+		if e.FileID != "1" || e.FileName != "pony.txt" || e.PartBytes != 5 || e.FileSize != 10 || e.PartNumbers != 2 {
+			t.Errorf("Progress of part is %+v", e)
+		}
+	}
+
+	want := []Phase{PhaseCreate, PhaseUpload, PhaseFinalize, PhaseDone}
+	if !reflect.DeepEqual(phases, want) {
+		t.Errorf("Progress phases are %v, want %v", phases, want)
+	}
+
+	if parts != 2 {
+		t.Errorf("Progress reported %v parts, want %v", parts, 2)
+	}
+
+	last := events[len(events)-1]
+	if last.Bytes != 10 || last.TotalSize != 10 || last.ID != "1" {
+		t.Errorf("Last progress is %+v, want 10 out of 10 bytes of transfer 1", last)
+	}
+}
+
+func TestProgressTracker_skip(t *testing.T) {
+	var got []Progress
+
+	buf := NewBuffer("pony.txt", []byte("yehaayehaa"))
+	ft := newFileTransfer(buf, &File{
+		ID:        String("1"),
+		Multipart: &Multipart{PartNumbers: Int64(2), ChunkSize: Int64(5)},
+	})
+
+	p := newProgressTracker(func(e Progress) { got = append(got, e) }, buf)
+	p.add(ft)
+	p.skip(ft, 1, 5)
+	p.part(ft, 2, 5)
+
+	if len(got) != 1 {
+		t.Fatalf("progressTracker reported %v events, want %v", len(got), 1)
+	}
+	if e := got[0]; e.PartsDone != 2 || e.FileBytes != 10 || e.Bytes != 10 {
+		t.Errorf("progressTracker reported %+v, want skipped part accounted for", e)
+	}
+}
+
+func TestProgressChan(t *testing.T) {
+	ch := make(chan Progress, 1)
+	ProgressChan(ch)(Progress{Phase: PhaseDone})
+
+	if got := <-ch; got.Phase != PhaseDone {
+		t.Errorf("ProgressChan sent %v, want %v", got.Phase, PhaseDone)
+	}
+}
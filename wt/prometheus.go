@@ -0,0 +1,158 @@
+package wt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// prometheusContentType is the content type of the Prometheus text exposition
+// format.
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// PrometheusHandler returns a handler serving the metrics of m in the
+// Prometheus text exposition format, to be mounted on a server scraped by
+// Prometheus. It fails with a 500 if the metrics cannot be exposed, see
+// WritePrometheus.
+func PrometheusHandler(m *MemoryMetrics) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		if err := m.WritePrometheus(&buf); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", prometheusContentType)
+		buf.WriteTo(w)
+	})
+}
+
+// WritePrometheus writes the metrics of m to w in the Prometheus text
+// exposition format. Nothing is written if a name is used by both a counter
+// and a histogram, which Prometheus does not allow.
+func (m *MemoryMetrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	counters := make(map[string][]*counterSeries)
+	for _, s := range m.counters {
+		c := *s
+		counters[s.name] = append(counters[s.name], &c)
+	}
+	histograms := make(map[string][]*histogramSeries)
+	for _, s := range m.histograms {
+		h := *s
+		h.counts = append([]uint64(nil), s.counts...)
+		histograms[s.name] = append(histograms[s.name], &h)
+	}
+	buckets := append([]float64(nil), m.bounds...)
+	m.mu.Unlock()
+
+	var names []string
+	for name := range counters {
+		names = append(names, name)
+	}
+	for name := range histograms {
+		if _, ok := counters[name]; ok {
+			return fmt.Errorf("metric %v is both a counter and a histogram", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+
+	for _, name := range names {
+		if help, ok := metricHelp[name]; ok {
+			bw.WriteString("# HELP " + name + " " + help + "\n")
+		}
+
+		if series, ok := counters[name]; ok {
+			sort.Slice(series, func(i, j int) bool {
+				return formatLabels(series[i].labels) < formatLabels(series[j].labels)
+			})
+
+			bw.WriteString("# TYPE " + name + " counter\n")
+			for _, s := range series {
+				writeSample(bw, name, s.labels, s.value)
+			}
+			continue
+		}
+
+		series := histograms[name]
+		sort.Slice(series, func(i, j int) bool {
+			return formatLabels(series[i].labels) < formatLabels(series[j].labels)
+		})
+
+		bw.WriteString("# TYPE " + name + " histogram\n")
+		for _, s := range series {
+			var cumulative uint64
+			for i, le := range buckets {
+				cumulative += s.counts[i]
+				writeSample(bw, name+"_bucket", withLabel(s.labels, "le", formatFloat(le)), float64(cumulative))
+			}
+			writeSample(bw, name+"_bucket", withLabel(s.labels, "le", "+Inf"), float64(s.count))
+			writeSample(bw, name+"_sum", s.labels, s.sum)
+			writeSample(bw, name+"_count", s.labels, float64(s.count))
+		}
+	}
+
+	return bw.Flush()
+}
+
+// writeSample writes a line of the exposition format.
+func writeSample(w *bufio.Writer, name string, labels []Label, value float64) {
+	w.WriteString(name)
+	w.WriteString(formatLabels(labels))
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+// withLabel returns a copy of labels with another label appended.
+func withLabel(labels []Label, name, value string) []Label {
+	l := make([]Label, len(labels), len(labels)+1)
+	copy(l, labels)
+	return append(l, Label{name, value})
+}
+
+// labelEscaper escapes the values of labels.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels returns labels as in the exposition format, or a blank string
+// if there are none.
+func formatLabels(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(l.Name)
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(l.Value))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// formatFloat returns v as in the exposition format.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,75 @@
+package wt
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPrometheusHandler(t *testing.T) {
+	m := NewMemoryMetrics()
+	m.Buckets = []float64{0.5, 1}
+
+	m.Add(MetricRetries, 1, Label{"operation", "part_upload"})
+	m.Add(MetricRetries, 2, Label{"operation", "api_request"})
+	m.Add("custom_total", 1, Label{"path", "a\"b\\c\nd"})
+	m.Observe(MetricPartUploadDuration, 0.25, Label{"status", "200"})
+	m.Observe(MetricPartUploadDuration, 2, Label{"status", "200"})
+
+	srv := httptest.NewServer(PrometheusHandler(m))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET returned error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got, want := resp.Header.Get("Content-Type"), prometheusContentType; got != want {
+		t.Errorf("Content-Type is %q, want %q", got, want)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading body returned error: %v", err)
+	}
+
+	want := strings.Join([]string{
+		`# TYPE custom_total counter`,
+		`custom_total{path="a\"b\\c\nd"} 1`,
+		`# HELP wt_part_upload_duration_seconds Duration of part uploads in seconds.`,
+		`# TYPE wt_part_upload_duration_seconds histogram`,
+		`wt_part_upload_duration_seconds_bucket{status="200",le="0.5"} 1`,
+		`wt_part_upload_duration_seconds_bucket{status="200",le="1"} 1`,
+		`wt_part_upload_duration_seconds_bucket{status="200",le="+Inf"} 2`,
+		`wt_part_upload_duration_seconds_sum{status="200"} 2.25`,
+		`wt_part_upload_duration_seconds_count{status="200"} 2`,
+		`# HELP wt_retries_total Retries by operation.`,
+		`# TYPE wt_retries_total counter`,
+		`wt_retries_total{operation="api_request"} 2`,
+		`wt_retries_total{operation="part_upload"} 1`,
+		``,
+	}, "\n")
+
+	if got := string(body); got != want {
+		t.Errorf("PrometheusHandler returned\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestPrometheusHandler_clash(t *testing.T) {
+	m := NewMemoryMetrics()
+	m.Add("pony", 1)
+	m.Observe("pony", 1)
+
+	if err := m.WritePrometheus(ioutil.Discard); err == nil || !strings.Contains(err.Error(), "pony") {
+		t.Errorf("WritePrometheus returned %v, want an error about pony", err)
+	}
+
+	rec := httptest.NewRecorder()
+	PrometheusHandler(m).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("PrometheusHandler returned status %v, want %v", rec.Code, http.StatusInternalServerError)
+	}
+}
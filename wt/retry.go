@@ -0,0 +1,176 @@
+package wt
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy describes how requests that failed because of a transient error
+// are retried. Only idempotent API requests, such as the retrieval of upload
+// URLs or the completion and finalization of uploads, and part uploads to the
+// storage are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first
+	// one. A value lower than 2 disables retries.
+	MaxAttempts int
+
+	// MinBackoff is the time waited before the first retry. It doubles on
+	// each subsequent retry.
+	MinBackoff time.Duration
+
+	// MaxBackoff caps the time waited between two attempts. It also caps the
+	// delay requested by a Retry-After header.
+	MaxBackoff time.Duration
+
+	// Jitter is the fraction, between 0 and 1, of the backoff that is
+	// randomized to spread the retries of concurrent requests.
+	Jitter float64
+
+	// RetryableStatus reports whether a response with the given status code
+	// is retried. If nil, 429 and 5xx responses except 501 are retried.
+	RetryableStatus func(code int) bool
+}
+
+// DefaultRetryPolicy returns the retry policy used by new clients.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 4,
+		MinBackoff:  250 * time.Millisecond,
+		MaxBackoff:  10 * time.Second,
+		Jitter:      0.2,
+	}
+}
+
+// maxAttempts returns the number of attempts allowed by the policy. A nil
+// policy allows a single attempt.
+func (p *RetryPolicy) maxAttempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// retryableStatus reports whether a response status code is worth a retry.
+func (p *RetryPolicy) retryableStatus(code int) bool {
+	if p != nil && p.RetryableStatus != nil {
+		return p.RetryableStatus(code)
+	}
+	return code == http.StatusTooManyRequests ||
+		code >= 500 && code != http.StatusNotImplemented
+}
+
+// shouldRetry reports whether another attempt can be made after the given
+// attempt (1-based) failed with resp or err.
+func (p *RetryPolicy) shouldRetry(ctx context.Context, attempt int, resp *http.Response, err error) bool {
+	if attempt >= p.maxAttempts() || ctx.Err() != nil {
+		return false
+	}
+	if resp != nil {
+		return p.retryableStatus(resp.StatusCode)
+	}
+	return isTransientError(err)
+}
+
+// backoff returns the time to wait after the given attempt (1-based). The
+// delay requested by the Retry-After header of resp, if any, takes precedence.
+func (p *RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if p == nil {
+		return 0
+	}
+
+	if d, ok := retryAfter(resp); ok {
+		if p.MaxBackoff > 0 && d > p.MaxBackoff {
+			d = p.MaxBackoff
+		}
+		return d
+	}
+
+	d := p.MinBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			d = p.MaxBackoff
+			break
+		}
+	}
+
+	if p.Jitter > 0 && d > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+
+	return d
+}
+
+// wait sleeps for the backoff of the given attempt or until ctx is done.
+func (p *RetryPolicy) wait(ctx context.Context, attempt int, resp *http.Response) error {
+	t := time.NewTimer(p.backoff(attempt, resp))
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retryAfter parses the Retry-After header of resp which is either a number
+// of seconds or an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+
+	return 0, false
+}
+
+// isTransientError reports whether err is a network error that might not
+// happen again, such as a reset connection or a timeout.
+func isTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var nerr net.Error
+	return errors.As(err, &nerr) && nerr.Timeout()
+}
+
+// isIdempotent reports whether a request with the given method can be sent
+// more than once without side effects.
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "PUT", "DELETE", "OPTIONS":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,271 @@
+package wt
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := &RetryPolicy{
+		MinBackoff: time.Second,
+		MaxBackoff: 5 * time.Second,
+	}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 5 * time.Second},
+		{10, 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := p.backoff(tt.attempt, nil); got != tt.want {
+			t.Errorf("RetryPolicy.backoff(%v) returned %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicy_backoff_jitter(t *testing.T) {
+	p := &RetryPolicy{
+		MinBackoff: time.Second,
+		Jitter:     0.5,
+	}
+
+	for i := 0; i < 100; i++ {
+		if got := p.backoff(1, nil); got < 500*time.Millisecond || got > time.Second {
+			t.Fatalf("RetryPolicy.backoff returned %v, want between 500ms and 1s", got)
+		}
+	}
+}
+
+func TestRetryPolicy_backoff_retryAfter(t *testing.T) {
+	p := &RetryPolicy{
+		MinBackoff: time.Second,
+		MaxBackoff: 10 * time.Second,
+	}
+
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"3", 3 * time.Second},
+		{"120", 10 * time.Second},
+		{"soon", time.Second},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		resp.Header.Set("Retry-After", tt.header)
+		if got := p.backoff(1, resp); got != tt.want {
+			t.Errorf("RetryPolicy.backoff with Retry-After %q returned %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicy_retryableStatus(t *testing.T) {
+	tests := []struct {
+		code int
+		want bool
+	}{
+		{200, false},
+		{400, false},
+		{404, false},
+		{429, true},
+		{500, true},
+		{501, false},
+		{503, true},
+	}
+
+	var p *RetryPolicy
+	for _, tt := range tests {
+		if got := p.retryableStatus(tt.code); got != tt.want {
+			t.Errorf("RetryPolicy.retryableStatus(%v) returned %v, want %v", tt.code, got, tt.want)
+		}
+	}
+
+	p = &RetryPolicy{RetryableStatus: func(code int) bool { return code == 404 }}
+	if !p.retryableStatus(404) || p.retryableStatus(503) {
+		t.Errorf("RetryPolicy.retryableStatus ignored RetryableStatus")
+	}
+}
+
+func TestClient_Do_retry(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	client.RetryPolicy = &RetryPolicy{MaxAttempts: 3}
+
+	attempts := 0
+	mux.HandleFunc("/transfers/1/finalize", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(503)
+			fmt.Fprint(w, `{"success": false, "message": "Service unavailable"}`)
+			return
+		}
+		fmt.Fprint(w, `{"success": true, "id": "1", "state": "done"}`)
+	})
+
+	_, err := client.Transfers.finalize(context.Background(), "1")
+	if err != nil {
+		t.Errorf("TransfersService.finalize returned an error: %v", err)
+	}
+
+	if attempts != 3 {
+		t.Errorf("Client.Do made %v attempts, want %v", attempts, 3)
+	}
+}
+
+func TestClient_Do_retry_exhausted(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	client.RetryPolicy = &RetryPolicy{MaxAttempts: 2}
+
+	attempts := 0
+	mux.HandleFunc("/transfers/1", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(429)
+		fmt.Fprint(w, `{"success": false, "message": "Too many requests"}`)
+	})
+
+	_, err := client.Transfers.Find(context.Background(), "1")
+	testErrorResponse(t, err, "Too many requests")
+
+	if attempts != 2 {
+		t.Errorf("Client.Do made %v attempts, want %v", attempts, 2)
+	}
+}
+
+func TestClient_Do_retry_notIdempotent(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	client.RetryPolicy = &RetryPolicy{MaxAttempts: 3}
+
+	attempts := 0
+	mux.HandleFunc("/transfers", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(503)
+		fmt.Fprint(w, `{"success": false, "message": "Service unavailable"}`)
+	})
+
+	_, err := client.Transfers.createTransfer(context.Background(), nil, NewBuffer("pony.txt", []byte("yehaa")))
+	if err == nil {
+		t.Errorf("Expected error to be returned")
+	}
+
+	if attempts != 1 {
+		t.Errorf("Client.Do made %v attempts, want %v", attempts, 1)
+	}
+}
+
+func TestUploaderService_uploadPart_expiredURL(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	client.RetryPolicy = &RetryPolicy{MaxAttempts: 3}
+
+	urls := 0
+	mux.HandleFunc("/transfers/1/files/1/upload-url/1", func(w http.ResponseWriter, r *http.Request) {
+		urls++
+		fmt.Fprintf(w, `{"success": true, "url": "%v/part/%v"}`, srvURL, urls)
+	})
+	mux.HandleFunc("/part/1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(403)
+		fmt.Fprint(w, `<Error><Code>AccessDenied</Code><Message>Request has expired</Message></Error>`)
+	})
+	mux.HandleFunc("/part/2", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	})
+
+	_, err := client.uploader.uploadPart(context.Background(), &Transfer{ID: String("1")}, "1", 1, "", []byte("yehaa"))
+	if err != nil {
+		t.Errorf("uploadPart returned an error: %v", err)
+	}
+
+	if urls != 2 {
+		t.Errorf("uploadPart requested %v upload URLs, want %v", urls, 2)
+	}
+}
+
+func TestUploaderService_uploadPart_serverError(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	client.RetryPolicy = &RetryPolicy{MaxAttempts: 3}
+
+	urls, puts := 0, 0
+	mux.HandleFunc("/transfers/1/files/1/upload-url/1", func(w http.ResponseWriter, r *http.Request) {
+		urls++
+		fmt.Fprintf(w, `{"success": true, "url": "%v/part/1"}`, srvURL)
+	})
+	mux.HandleFunc("/part/1", func(w http.ResponseWriter, r *http.Request) {
+		puts++
+		if puts == 1 {
+			w.WriteHeader(500)
+		}
+	})
+
+	_, err := client.uploader.uploadPart(context.Background(), &Transfer{ID: String("1")}, "1", 1, "", []byte("yehaa"))
+	if err != nil {
+		t.Errorf("uploadPart returned an error: %v", err)
+	}
+
+	if urls != 1 || puts != 2 {
+		t.Errorf("uploadPart requested %v URLs and %v uploads, want %v and %v", urls, puts, 1, 2)
+	}
+}
+
+func TestUploaderService_uploadPart_checksumMismatch(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	client.RetryPolicy = &RetryPolicy{MaxAttempts: 3}
+
+	data := []byte("yehaa")
+	sum := md5.Sum(data)
+
+	puts := 0
+	mux.HandleFunc("/transfers/1/files/1/upload-url/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"success": true, "url": "%v/part/1"}`, srvURL)
+	})
+	mux.HandleFunc("/part/1", func(w http.ResponseWriter, r *http.Request) {
+		puts++
+		if puts == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `<Error><Code>BadDigest</Code></Error>`)
+			return
+		}
+		w.Header().Set("ETag", fmt.Sprintf("%q", hex.EncodeToString(sum[:])))
+	})
+
+	digest, err := client.uploader.uploadPart(context.Background(), &Transfer{ID: String("1")}, "1", 1, "", data)
+	if err != nil {
+		t.Fatalf("uploadPart returned an error: %v", err)
+	}
+
+	if puts != 2 {
+		t.Errorf("uploadPart sent %v uploads, want %v", puts, 2)
+	}
+
+	want := &PartDigest{
+		PartNumber: 1,
+		Size:       int64(len(data)),
+		MD5:        hex.EncodeToString(sum[:]),
+		ETag:       fmt.Sprintf("%q", hex.EncodeToString(sum[:])),
+	}
+	if !reflect.DeepEqual(digest, want) {
+		t.Errorf("uploadPart returned %+v, want %+v", digest, want)
+	}
+}
@@ -0,0 +1,150 @@
+package wt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// TransferSession is a checkpoint of a transfer being uploaded. It holds the
+// transfer as acknowledged by WeTransfer, which includes the IDs and multipart
+// info of its files, and the part numbers of each file uploaded so far. A
+// session can be serialized to JSON, or saved to a file, so an interrupted
+// upload can be picked up by Transfers.Resume.
+type TransferSession struct {
+	Transfer *Transfer `json:"transfer"`
+
+	// Uploaded maps file IDs to the part numbers uploaded successfully.
+	Uploaded map[string][]int64 `json:"uploaded"`
+
+	mu      sync.Mutex
+	path    string // file the session is saved to after each part
+	saveErr error  // first error that occurred while saving
+}
+
+// NewTransferSession returns a session of a created transfer with no parts
+// uploaded yet.
+func NewTransferSession(transfer *Transfer) *TransferSession {
+	return &TransferSession{
+		Transfer: transfer,
+		Uploaded: make(map[string][]int64),
+	}
+}
+
+// LoadTransferSession reads a session previously saved to the named file. The
+// session keeps being saved to the same file as parts are uploaded.
+func LoadTransferSession(name string) (*TransferSession, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &TransferSession{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if s.Uploaded == nil {
+		s.Uploaded = make(map[string][]int64)
+	}
+	s.path = name
+
+	return s, nil
+}
+
+// Persist saves the session to the named file, and keeps saving it every time
+// a part is uploaded.
+func (s *TransferSession) Persist(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.path = name
+	return s.save()
+}
+
+// IsUploaded reports whether the part of a file has been uploaded.
+func (s *TransferSession) IsUploaded(fileID string, partNum int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, n := range s.Uploaded[fileID] {
+		if n == partNum {
+			return true
+		}
+	}
+	return false
+}
+
+// markUploaded records a part of a file as uploaded and saves the session if
+// it is persisted.
+func (s *TransferSession) markUploaded(fileID string, partNum int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Uploaded == nil {
+		s.Uploaded = make(map[string][]int64)
+	}
+
+	parts := append(s.Uploaded[fileID], partNum)
+	sort.Slice(parts, func(i, j int) bool { return parts[i] < parts[j] })
+	s.Uploaded[fileID] = parts
+
+	if err := s.save(); err != nil && s.saveErr == nil {
+		s.saveErr = err
+	}
+}
+
+// complete reports whether all parts of the file have been uploaded.
+func (s *TransferSession) complete(f *File) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return int64(len(s.Uploaded[f.GetID()])) >= f.GetMultipart().GetPartNumbers()
+}
+
+// err returns the first error that occurred while saving the session.
+func (s *TransferSession) err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.saveErr
+}
+
+// save writes the session to its file, if any. The file is replaced
+// atomically so a crash never leaves a truncated session behind. It must be
+// called with s.mu held.
+func (s *TransferSession) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("saving transfer session: %v", err)
+	}
+
+	return nil
+}
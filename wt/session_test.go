@@ -0,0 +1,197 @@
+package wt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testSessionTransfer() *Transfer {
+	return &Transfer{
+		ID: String("1"),
+		Files: []*File{
+			{
+				ID:   String("1"),
+				Name: String("pony.txt"),
+				Size: Int64(8),
+				Multipart: &Multipart{
+					PartNumbers: Int64(2),
+					ChunkSize:   Int64(4),
+				},
+			},
+		},
+	}
+}
+
+func TestTransferSession_json(t *testing.T) {
+	s := NewTransferSession(testSessionTransfer())
+	s.markUploaded("1", 2)
+	s.markUploaded("1", 1)
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("json.Marshal returned an error: %v", err)
+	}
+
+	got := &TransferSession{}
+	if err := json.Unmarshal(data, got); err != nil {
+		t.Fatalf("json.Unmarshal returned an error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got.Transfer, s.Transfer) {
+		t.Errorf("TransferSession.Transfer is %v, want %v", got.Transfer, s.Transfer)
+	}
+	if want := []int64{1, 2}; !reflect.DeepEqual(got.Uploaded["1"], want) {
+		t.Errorf("TransferSession.Uploaded is %v, want %v", got.Uploaded["1"], want)
+	}
+}
+
+func TestTransferSession_Persist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wt-go-sdk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "session.json")
+
+	s := NewTransferSession(testSessionTransfer())
+	if err := s.Persist(name); err != nil {
+		t.Fatalf("TransferSession.Persist returned an error: %v", err)
+	}
+
+	// Every uploaded part is saved right away.
+	s.markUploaded("1", 1)
+
+	loaded, err := LoadTransferSession(name)
+	if err != nil {
+		t.Fatalf("LoadTransferSession returned an error: %v", err)
+	}
+
+	if !loaded.IsUploaded("1", 1) {
+		t.Errorf("TransferSession.IsUploaded(1, 1) returned false, want true")
+	}
+	if loaded.IsUploaded("1", 2) {
+		t.Errorf("TransferSession.IsUploaded(1, 2) returned true, want false")
+	}
+	if got := loaded.Transfer.GetID(); got != "1" {
+		t.Errorf("TransferSession.Transfer.ID is %v, want %v", got, "1")
+	}
+}
+
+func TestTransfersService_Resume(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	var got []byte
+
+	mux.HandleFunc("/transfers/1/files/1/upload-url/1", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Resume requested an upload URL for an uploaded part")
+	})
+	mux.HandleFunc("/transfers/1/files/1/upload-url/2", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"success": true, "url": "%v/part/2"}`, srvURL)
+	})
+	mux.HandleFunc("/part/2", func(w http.ResponseWriter, r *http.Request) {
+		got, _ = ioutil.ReadAll(r.Body)
+	})
+	mux.HandleFunc("/transfers/1/files/1/upload-complete", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		fmt.Fprint(w, `{"id": "1", "retries": 0, "name": "pony.txt", "size": 8, "chunk_size": 4}`)
+	})
+	mux.HandleFunc("/transfers/1/finalize", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		fmt.Fprint(w, `{"success": true, "id": "1", "state": "done", "url": "https://we.tl/t-1"}`)
+	})
+
+	session := NewTransferSession(testSessionTransfer())
+	session.markUploaded("1", 1)
+
+	buf := NewBuffer("pony.txt", []byte("xxxxyyyy"))
+	transfer, err := client.Transfers.Resume(context.Background(), session, buf)
+	if err != nil {
+		t.Fatalf("TransfersService.Resume returned an error: %v", err)
+	}
+
+	if want := "yyyy"; string(got) != want {
+		t.Errorf("TransfersService.Resume uploaded %q, want %q", got, want)
+	}
+	if !session.IsUploaded("1", 2) {
+		t.Errorf("TransfersService.Resume did not record part 2 as uploaded")
+	}
+	if got, want := transfer.GetURL(), "https://we.tl/t-1"; got != want {
+		t.Errorf("TransfersService.Resume returned URL %v, want %v", got, want)
+	}
+}
+
+func TestTransfersService_Resume_missingUploadable(t *testing.T) {
+	client, _, _, teardown := setup()
+	defer teardown()
+
+	session := NewTransferSession(testSessionTransfer())
+	session.markUploaded("1", 1)
+	session.markUploaded("1", 2)
+
+	// Even the uploadables of uploaded files must be passed.
+	_, err := client.Transfers.Resume(context.Background(), session)
+	if err == nil || !strings.Contains(err.Error(), "has 1 files, got 0 uploadables") {
+		t.Errorf("TransfersService.Resume returned %v, want an error about the missing uploadable", err)
+	}
+}
+
+func TestTransfersService_Resume_duplicateNames(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	var got []byte
+
+	mux.HandleFunc("/transfers/1/files/2/upload-url/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"success": true, "url": "%v/part/1"}`, srvURL)
+	})
+	mux.HandleFunc("/part/1", func(w http.ResponseWriter, r *http.Request) {
+		got, _ = ioutil.ReadAll(r.Body)
+	})
+	mux.HandleFunc("/transfers/1/files/1/upload-complete", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": "1"}`)
+	})
+	mux.HandleFunc("/transfers/1/files/2/upload-complete", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": "2"}`)
+	})
+	mux.HandleFunc("/transfers/1/finalize", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": "1", "state": "processing"}`)
+	})
+
+	file := func(id, name string) *File {
+		return &File{
+			ID:        String(id),
+			Name:      String(name),
+			Size:      Int64(4),
+			Multipart: &Multipart{PartNumbers: Int64(1), ChunkSize: Int64(4)},
+		}
+	}
+	session := NewTransferSession(&Transfer{
+		ID:    String("1"),
+		Files: []*File{file("1", "report.pdf"), file("2", "report (1).pdf")},
+	})
+	session.markUploaded("1", 1)
+
+	first := NewBuffer("report.pdf", []byte("aaaa"))
+	second := NewBuffer("report.pdf", []byte("bbbb"))
+
+	if _, err := client.Transfers.Resume(context.Background(), session, second); err == nil {
+		t.Errorf("TransfersService.Resume of a single duplicate returned no error")
+	}
+
+	if _, err := client.Transfers.Resume(context.Background(), session, first, second); err != nil {
+		t.Fatalf("TransfersService.Resume returned an error: %v", err)
+	}
+	if want := "bbbb"; string(got) != want {
+		t.Errorf("TransfersService.Resume uploaded %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,214 @@
+package wt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+const defaultSpoolMemory = 8 * 1024 * 1024
+
+// SpoolOptions configures how a Spool stores the content of a reader.
+type SpoolOptions struct {
+	// MaxMemory is the number of bytes kept in memory. The rest is written
+	// to a temporary file. Defaults to 8 MiB.
+	MaxMemory int64
+
+	// MaxSize is the maximum number of bytes read from the reader. Zero
+	// means no limit.
+	MaxSize int64
+
+	// Dir is the directory of the temporary file. Defaults to the default
+	// directory for temporary files.
+	Dir string
+}
+
+// Spool implements the Uploadable interface for a reader of unknown length,
+// such as a pipe or an HTTP response body. The reader is read to the end when
+// the spool is created, so its size is known before the transfer is created.
+// Spools must be closed to remove their temporary file.
+type Spool struct {
+	name string
+	size int64
+	mem  []byte
+	path string // temporary file holding the content past mem, if any
+}
+
+// NewSpool reads r to the end and returns a Spool of its content.
+func NewSpool(name string, r io.Reader, opts *SpoolOptions) (*Spool, error) {
+	if opts == nil {
+		opts = &SpoolOptions{}
+	}
+
+	maxMemory := opts.MaxMemory
+	if maxMemory <= 0 {
+		maxMemory = defaultSpoolMemory
+	}
+	if opts.MaxSize > 0 {
+		// Read one more byte to tell whether the limit is exceeded.
+		r = io.LimitReader(r, opts.MaxSize+1)
+	}
+
+	s := &Spool{name: name}
+
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, r, maxMemory)
+	s.mem, s.size = buf.Bytes(), n
+	if err == io.EOF {
+		if err := s.checkSize(opts.MaxSize); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := ioutil.TempFile(opts.Dir, "wt-spool-")
+	if err != nil {
+		return nil, err
+	}
+	s.path = f.Name()
+
+	n, err = io.Copy(f, r)
+	s.size += n
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = s.checkSize(opts.MaxSize)
+	}
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Spool) checkSize(max int64) error {
+	if max > 0 && s.size > max {
+		s.Close()
+		return fmt.Errorf("spool %v exceeds %d bytes", s.name, max)
+	}
+	return nil
+}
+
+// Stat returns the name and the size of the spooled content.
+func (s *Spool) Stat() (string, int64) {
+	return s.name, s.size
+}
+
+// Open returns a reader of the spooled content.
+func (s *Spool) Open() (io.ReadCloser, error) {
+	if s.path == "" {
+		return newBytesReadCloser(s.mem), nil
+	}
+
+	mem := bytes.NewReader(s.mem)
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	return &multiReadCloser{Reader: io.MultiReader(mem, f), Closer: f}, nil
+}
+
+// Close removes the temporary file of the spool, if any.
+func (s *Spool) Close() error {
+	if s.path == "" {
+		return nil
+	}
+	err := os.Remove(s.path)
+	s.path = ""
+	return err
+}
+
+type multiReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Stream implements the Uploadable interface for a reader of known length
+// that can only be read once, such as an HTTP response body with a
+// Content-Length. Its content is uploaded as it arrives, without being
+// stored first. Since it cannot be read again, a stream cannot be uploaded
+// twice nor resumed; use a Spool for that.
+//
+// The size must be exact, not an upper bound, because the API splits files
+// into parts when the transfer is created. Reading fails if the reader yields
+// fewer or more bytes.
+type Stream struct {
+	name string
+	size int64
+
+	mu   sync.Mutex
+	r    io.Reader
+	used bool
+}
+
+// NewStream returns a Stream of the size bytes of r.
+func NewStream(name string, r io.Reader, size int64) *Stream {
+	return &Stream{
+		name: name,
+		size: size,
+		r:    r,
+	}
+}
+
+// Stat returns the name and the size of the stream.
+func (s *Stream) Stat() (string, int64) {
+	return s.name, s.size
+}
+
+// Open returns the reader of the stream. It fails if called more than once.
+func (s *Stream) Open() (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.used {
+		return nil, fmt.Errorf("stream %v can only be read once", s.name)
+	}
+	s.used = true
+
+	rc := &sizedReader{r: s.r, name: s.name, size: s.size, left: s.size}
+	if c, ok := s.r.(io.Closer); ok {
+		return &multiReadCloser{Reader: rc, Closer: c}, nil
+	}
+	return ioutil.NopCloser(rc), nil
+}
+
+// sizedReader reads exactly size bytes from r.
+type sizedReader struct {
+	r    io.Reader
+	name string
+	size int64
+	left int64
+}
+
+func (s *sizedReader) Read(p []byte) (int, error) {
+	if s.left == 0 {
+		// Make sure the reader has nothing more to yield.
+		var b [1]byte
+		if n, _ := io.ReadFull(s.r, b[:]); n > 0 {
+			return 0, fmt.Errorf("stream %v is longer than %d bytes", s.name, s.size)
+		}
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > s.left {
+		p = p[:s.left]
+	}
+	n, err := s.r.Read(p)
+	s.left -= int64(n)
+	if err == io.EOF && s.left > 0 {
+		return n, fmt.Errorf("stream %v is shorter than %d bytes, got %d", s.name, s.size, s.size-s.left)
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
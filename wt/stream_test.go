@@ -0,0 +1,201 @@
+package wt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNewSpool_memory(t *testing.T) {
+	s, err := NewSpool("pony.txt", strings.NewReader("yeehaaa"), nil)
+	if err != nil {
+		t.Fatalf("NewSpool returned error: %v", err)
+	}
+	defer s.Close()
+
+	if s.path != "" {
+		t.Errorf("NewSpool created temporary file %v", s.path)
+	}
+
+	testUploadable(t, s, "pony.txt", "yeehaaa")
+}
+
+func TestNewSpool_tempFile(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := NewSpool("pony.txt", strings.NewReader("yeehaaa"), &SpoolOptions{MaxMemory: 3, Dir: dir})
+	if err != nil {
+		t.Fatalf("NewSpool returned error: %v", err)
+	}
+
+	path := s.path
+	if path == "" {
+		t.Fatal("NewSpool did not create a temporary file")
+	}
+
+	// Spools can be opened more than once.
+	testUploadable(t, s, "pony.txt", "yeehaaa")
+	testUploadable(t, s, "pony.txt", "yeehaaa")
+
+	if err := s.Close(); err != nil {
+		t.Errorf("Close returned error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Close did not remove %v", path)
+	}
+}
+
+func TestNewSpool_maxSize(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, maxMemory := range []int64{2, 100} {
+		s, err := NewSpool("pony.txt", strings.NewReader("yeehaaa"), &SpoolOptions{MaxMemory: maxMemory, MaxSize: 6, Dir: dir})
+		if err == nil || s != nil {
+			t.Errorf("NewSpool with MaxMemory %v returned %v, %v, want nil and an error", maxMemory, s, err)
+		}
+	}
+
+	if files, _ := ioutil.ReadDir(dir); len(files) > 0 {
+		t.Errorf("NewSpool left %v temporary file(s)", len(files))
+	}
+
+	s, err := NewSpool("pony.txt", strings.NewReader("yeehaaa"), &SpoolOptions{MaxSize: 7})
+	if err != nil {
+		t.Fatalf("NewSpool returned error: %v", err)
+	}
+	testUploadable(t, s, "pony.txt", "yeehaaa")
+}
+
+func TestStream(t *testing.T) {
+	s := NewStream("pony.txt", strings.NewReader("yeehaaa"), 7)
+
+	testUploadable(t, s, "pony.txt", "yeehaaa")
+
+	if _, err := s.Open(); err == nil {
+		t.Error("Open of a used stream returned no error")
+	}
+}
+
+func TestStream_wrongSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{6, "longer"},
+		{8, "shorter"},
+	}
+
+	for _, tt := range tests {
+		rc, _ := NewStream("pony.txt", strings.NewReader("yeehaaa"), tt.size).Open()
+		_, err := ioutil.ReadAll(rc)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Stream of size %v returned %v, want an error about being %v", tt.size, err, tt.want)
+		}
+	}
+}
+
+func TestUploaderService_upload_stream(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	var (
+		mu  sync.Mutex
+		got = make(map[string]string)
+	)
+
+	for _, p := range []string{"1", "2"} {
+		p := p
+		mux.HandleFunc("/transfers/1/files/1/upload-url/"+p, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"success": true, "url": "%v/part/%v"}`, srvURL, p)
+		})
+		mux.HandleFunc("/part/"+p, func(w http.ResponseWriter, r *http.Request) {
+			b, _ := ioutil.ReadAll(r.Body)
+			mu.Lock()
+			got[p] = string(b)
+			mu.Unlock()
+		})
+	}
+
+	// A pipe yields data as it is written, in pieces smaller than a part.
+	pr, pw := io.Pipe()
+	go func() {
+		for _, s := range []string{"ab", "cd", "e", "fgh"} {
+			pw.Write([]byte(s))
+		}
+		pw.Close()
+	}()
+
+	file := &File{
+		ID:   String("1"),
+		Name: String("pipe.txt"),
+		Multipart: &Multipart{
+			PartNumbers: Int64(2),
+			ChunkSize:   Int64(5),
+		},
+	}
+
+	ft := newFileTransfer(NewStream("pipe.txt", pr, 8), file)
+	if err := client.uploader.upload(context.Background(), &Transfer{ID: String("1")}, ft); err != nil {
+		t.Fatalf("upload returned an error: %v", err)
+	}
+
+	if got["1"] != "abcde" || got["2"] != "fgh" {
+		t.Errorf("upload sent parts %q, want [abcde fgh]", got)
+	}
+}
+
+func TestUploaderService_upload_streamTooLong(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/transfers/1/files/1/upload-url/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"success": true, "url": "%v/part/1"}`, srvURL)
+	})
+	mux.HandleFunc("/part/1", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the last part of a stream longer than its size was uploaded")
+	})
+
+	file := &File{
+		ID:   String("1"),
+		Name: String("x.txt"),
+		Multipart: &Multipart{
+			PartNumbers: Int64(1),
+			ChunkSize:   Int64(5),
+		},
+	}
+
+	ft := newFileTransfer(NewStream("x.txt", strings.NewReader("hello world"), 5), file)
+	err := client.uploader.upload(context.Background(), &Transfer{ID: String("1")}, ft)
+	if err == nil || !strings.Contains(err.Error(), "longer than 5 bytes") {
+		t.Errorf("upload returned %v, want an error about the stream being longer", err)
+	}
+}
+
+func testUploadable(t *testing.T, up Uploadable, name, content string) {
+	t.Helper()
+
+	gotName, gotSize := up.Stat()
+	if gotName != name || gotSize != int64(len(content)) {
+		t.Errorf("Stat returned %v, %v, want %v, %v", gotName, gotSize, name, len(content))
+	}
+
+	rc, err := up.Open()
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer rc.Close()
+
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("Read returned error: %v", err)
+	}
+	if string(b) != content {
+		t.Errorf("Open read %q, want %q", b, content)
+	}
+}
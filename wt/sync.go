@@ -0,0 +1,252 @@
+package wt
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SyncActionKind is the kind of a change made to a board by Sync.
+type SyncActionKind string
+
+// Changes made to a board by Sync.
+const (
+	SyncUpload  SyncActionKind = "upload"   // a new file is uploaded
+	SyncReplace SyncActionKind = "replace"  // a changed file is uploaded and its previous items removed
+	SyncAddLink SyncActionKind = "add-link" // a link is added
+	SyncSkip    SyncActionKind = "skip"     // an empty file, which cannot be uploaded, is left out
+)
+
+// SyncAction is a change made, or planned, by Sync.
+type SyncAction struct {
+	Kind SyncActionKind
+
+	// Name and Size of the local file, for uploads and skipped files.
+	Name string
+	Size int64
+
+	// URL of the link, for added links.
+	URL string
+
+	// ItemIDs of the items replaced.
+	ItemIDs []string
+}
+
+// SyncOptions configures Sync.
+type SyncOptions struct {
+	// Files selects the files of the directory. Hidden files and symbolic
+	// links are left out by default.
+	Files DirectoryOptions
+
+	// LinksFile is the path, relative to the directory, of a file listing
+	// links to add to the board. Each line holds a URL, optionally followed
+	// by a title. Blank lines and lines starting with # are ignored. The
+	// file itself is not uploaded.
+	LinksFile string
+
+	// DryRun plans the changes without making them.
+	DryRun bool
+}
+
+// SyncResult describes the outcome of Sync.
+type SyncResult struct {
+	// Board is the board as found before the sync, updated with the changes
+	// made.
+	Board *Board
+
+	// Actions are the changes made, or planned in a dry run.
+	Actions []*SyncAction
+
+	// Unchanged is the number of local files already on the board.
+	Unchanged int
+}
+
+// Sync mirrors the files of a local directory to a board. Files are compared
+// with the file items of the board by name and size: new files are uploaded
+// and files whose size changed are uploaded again, their previous items being
+// removed once the upload succeeded. Empty files cannot be uploaded, and are
+// reported as skipped. Files and items are never removed otherwise. Links listed in the links file are added unless already on the
+// board.
+//
+// Files are named after their base name, and those sharing a name are
+// handled according to the DuplicateNames policy of the client, the same way
+// on every sync.
+func (b *BoardsService) Sync(ctx context.Context, board *Board, dir string, opts *SyncOptions) (result *SyncResult, err error) {
+	ctx, span := b.client.startSpan(ctx, "wt.Boards.Sync", idAttr(board))
+	defer func() { span.end(err) }()
+
+	if opts == nil {
+		opts = &SyncOptions{}
+	}
+
+	files := opts.Files
+	up, err := NewDirectory(dir, &files)
+	if err != nil {
+		return nil, err
+	}
+	if opts.LinksFile != "" {
+		up = withoutFile(up, filepath.Join(dir, opts.LinksFile))
+	}
+	up, err = uniqueNames(b.client.DuplicateNames, up)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []*Link
+	if opts.LinksFile != "" {
+		if links, err = readLinks(filepath.Join(dir, opts.LinksFile)); err != nil {
+			return nil, err
+		}
+	}
+
+	board, err = b.Find(ctx, board.GetID())
+	if err != nil {
+		return nil, err
+	}
+
+	result = &SyncResult{Board: board}
+
+	// Plan the changes.
+	items := make(map[string][]*Item)
+	for _, item := range board.Items {
+		if item.IsFile() {
+			items[item.GetName()] = append(items[item.GetName()], item)
+		}
+	}
+
+	var (
+		uploads  []Uploadable
+		replaced []string
+	)
+
+	for _, u := range up {
+		name, size := u.Stat()
+		if size == 0 {
+			result.Actions = append(result.Actions, &SyncAction{Kind: SyncSkip, Name: name})
+			continue
+		}
+		existing := items[name]
+
+		action := &SyncAction{Kind: SyncUpload, Name: name, Size: size}
+		if len(existing) > 0 {
+			if hasSize(existing, size) {
+				result.Unchanged++
+				continue
+			}
+			action.Kind = SyncReplace
+			for _, item := range existing {
+				action.ItemIDs = append(action.ItemIDs, item.GetID())
+			}
+			replaced = append(replaced, action.ItemIDs...)
+		}
+
+		result.Actions = append(result.Actions, action)
+		uploads = append(uploads, u)
+	}
+
+	seen := make(map[string]bool)
+	for _, item := range board.Items {
+		if item.IsLink() {
+			seen[linkKey(item.GetURL())] = true
+		}
+	}
+
+	var newLinks []*Link
+	for _, link := range links {
+		if key := linkKey(*link.URL); !seen[key] {
+			seen[key] = true
+			newLinks = append(newLinks, link)
+			result.Actions = append(result.Actions, &SyncAction{Kind: SyncAddLink, URL: *link.URL})
+		}
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	// Make them.
+	if len(uploads) > 0 {
+		added, err := b.AddFiles(ctx, board, uploads...)
+		if err != nil {
+			return nil, err
+		}
+		board.Items = append(board.Items, added...)
+	}
+
+	if len(newLinks) > 0 {
+		if _, err := b.AddNewLinks(ctx, board, newLinks...); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(replaced) > 0 {
+		if err := b.RemoveItems(ctx, board, replaced...); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// hasSize reports whether one of the items has the given size.
+func hasSize(items []*Item, size int64) bool {
+	for _, item := range items {
+		if item.GetSize() == size {
+			return true
+		}
+	}
+	return false
+}
+
+// readLinks reads a file of links, one per line, each URL being optionally
+// followed by a title.
+func readLinks(path string) ([]*Link, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var links []*Link
+
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var title *string
+		u := line
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			u = line[:i]
+			title = String(strings.TrimSpace(line[i:]))
+		}
+
+		link, err := NewLink(u, title)
+		if err != nil {
+			return nil, fmt.Errorf("%v:%d: %v", path, n, err)
+		}
+		links = append(links, link)
+	}
+
+	return links, s.Err()
+}
+
+// withoutFile returns the uploadables but the local file at path. Only that
+// file is left out, not those sharing its name in other directories.
+func withoutFile(up []Uploadable, path string) []Uploadable {
+	path = filepath.Clean(path)
+
+	kept := make([]Uploadable, 0, len(up))
+	for _, u := range up {
+		if l, ok := u.(*LocalFile); ok && filepath.Clean(l.filepath) == path {
+			continue
+		}
+		kept = append(kept, u)
+	}
+	return kept
+}
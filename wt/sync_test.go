@@ -0,0 +1,240 @@
+package wt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// setupSyncDir creates a directory to sync and returns its path.
+func setupSyncDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"same.txt":    "same",
+		"changed.txt": "now longer",
+		"new.txt":     "new",
+		"links.txt":   "# links\nhttps://example.com\nhttps://example.org  Example org\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+const syncBoard = `{
+	"id": "1",
+	"name": "Mirror",
+	"items": [
+		{"id": "a", "name": "same.txt", "size": 4, "type": "file"},
+		{"id": "b", "name": "changed.txt", "size": 3, "type": "file"},
+		{"id": "c", "name": "gone.txt", "size": 3, "type": "file"},
+		{"id": "d", "url": "https://example.com/", "type": "link"}
+	]
+}`
+
+func TestBoardsService_Sync_dryRun(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/boards/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, syncBoard)
+	})
+
+	result, err := client.Boards.Sync(context.Background(), &Board{ID: String("1")}, setupSyncDir(t), &SyncOptions{
+		LinksFile: "links.txt",
+		DryRun:    true,
+	})
+	if err != nil {
+		t.Fatalf("Boards.Sync returned error: %v", err)
+	}
+
+	want := []*SyncAction{
+		{Kind: SyncReplace, Name: "changed.txt", Size: 10, ItemIDs: []string{"b"}},
+		{Kind: SyncUpload, Name: "new.txt", Size: 3},
+		{Kind: SyncAddLink, URL: "https://example.org/"},
+	}
+	if !reflect.DeepEqual(result.Actions, want) {
+		t.Errorf("Boards.Sync planned %v, want %v", result.Actions, want)
+	}
+	if result.Unchanged != 1 {
+		t.Errorf("Boards.Sync found %v unchanged files, want 1", result.Unchanged)
+	}
+	if got, want := result.Board.GetName(), "Mirror"; got != want {
+		t.Errorf("Boards.Sync board is %v, want %v", got, want)
+	}
+}
+
+func TestBoardsService_Sync_linksFileOnly(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/boards/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": "1", "items": []}`)
+	})
+
+	// Only the links file itself is left out, neither the files sharing its
+	// name elsewhere nor those its name would match as a pattern.
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "sub"), 0755)
+	for name, content := range map[string]string{
+		"[x].txt":     "# no links\n",
+		"x.txt":       "x",
+		"sub/[x].txt": "sub",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := client.Boards.Sync(context.Background(), &Board{ID: String("1")}, dir, &SyncOptions{
+		LinksFile: "[x].txt",
+		DryRun:    true,
+	})
+	if err != nil {
+		t.Fatalf("Boards.Sync returned error: %v", err)
+	}
+
+	want := []*SyncAction{
+		{Kind: SyncUpload, Name: "[x].txt", Size: 3},
+		{Kind: SyncUpload, Name: "x.txt", Size: 1},
+	}
+	if !reflect.DeepEqual(result.Actions, want) {
+		t.Errorf("Boards.Sync planned %v, want %v", result.Actions, want)
+	}
+}
+
+func TestBoardsService_Sync_emptyFile(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	// Any request but the board retrieval fails.
+	mux.HandleFunc("/boards/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"id": "1", "items": []}`)
+	})
+
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "empty.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := client.Boards.Sync(context.Background(), &Board{ID: String("1")}, dir, nil)
+	if err != nil {
+		t.Fatalf("Boards.Sync returned error: %v", err)
+	}
+
+	want := []*SyncAction{{Kind: SyncSkip, Name: "empty.txt"}}
+	if !reflect.DeepEqual(result.Actions, want) {
+		t.Errorf("Boards.Sync made %v, want %v", result.Actions, want)
+	}
+}
+
+func TestBoardsService_Sync(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	var (
+		mu       sync.Mutex
+		uploaded []string
+		links    []*Link
+		removed  []string
+	)
+
+	mux.HandleFunc("/boards/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, syncBoard)
+	})
+	mux.HandleFunc("/boards/1/files", func(w http.ResponseWriter, r *http.Request) {
+		var files []fileObject
+		json.NewDecoder(r.Body).Decode(&files)
+
+		var items []*Item
+		for _, f := range files {
+			mu.Lock()
+			uploaded = append(uploaded, f.Name)
+			mu.Unlock()
+			items = append(items, &Item{
+				ID:        String("new-" + f.Name),
+				Name:      String(f.Name),
+				Size:      Int64(f.Size),
+				Type:      String("file"),
+				Multipart: &Multipart{ID: String("m"), PartNumbers: Int64(1), ChunkSize: Int64(f.Size)},
+			})
+		}
+		json.NewEncoder(w).Encode(items)
+	})
+	for _, name := range []string{"changed.txt", "new.txt"} {
+		mux.HandleFunc(fmt.Sprintf("/boards/1/files/new-%v/upload-url/1/m", name), func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"success": true, "url": "%v/s3"}`, srvURL)
+		})
+		mux.HandleFunc(fmt.Sprintf("/boards/1/files/new-%v/upload-complete", name), func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"success": true}`)
+		})
+	}
+	mux.HandleFunc("/s3", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/boards/1/links", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&links)
+		fmt.Fprint(w, `[{"id": "e", "url": "https://example.org/", "type": "link"}]`)
+	})
+	mux.HandleFunc("/boards/1/items/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		removed = append(removed, filepath.Base(r.URL.Path))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	result, err := client.Boards.Sync(context.Background(), &Board{ID: String("1")}, setupSyncDir(t), &SyncOptions{
+		LinksFile: "links.txt",
+	})
+	if err != nil {
+		t.Fatalf("Boards.Sync returned error: %v", err)
+	}
+
+	if want := []string{"changed.txt", "new.txt"}; !reflect.DeepEqual(uploaded, want) {
+		t.Errorf("Boards.Sync uploaded %v, want %v", uploaded, want)
+	}
+	if want := []*Link{{URL: String("https://example.org/"), Title: String("Example org")}}; !reflect.DeepEqual(links, want) {
+		t.Errorf("Boards.Sync added links %v, want %v", links, want)
+	}
+	if want := []string{"b"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("Boards.Sync removed %v, want %v", removed, want)
+	}
+
+	var ids []string
+	for _, item := range result.Board.Items {
+		ids = append(ids, item.GetID())
+	}
+	if want := []string{"a", "c", "d", "new-changed.txt", "new-new.txt", "e"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Boards.Sync left items %v, want %v", ids, want)
+	}
+}
+
+func TestBoardsService_Sync_badLinksFile(t *testing.T) {
+	client, _, _, teardown := setup()
+	defer teardown()
+
+	dir := setupSyncDir(t)
+	if err := ioutil.WriteFile(filepath.Join(dir, "links.txt"), []byte("not-a-url\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := client.Boards.Sync(context.Background(), &Board{ID: String("1")}, dir, &SyncOptions{LinksFile: "links.txt"})
+	if err == nil {
+		t.Error("Expected error to be returned")
+	}
+
+	os.Remove(filepath.Join(dir, "links.txt"))
+	_, err = client.Boards.Sync(context.Background(), &Board{ID: String("1")}, dir, &SyncOptions{LinksFile: "links.txt"})
+	if !os.IsNotExist(err) {
+		t.Errorf("Boards.Sync returned %v, want a missing file error", err)
+	}
+}
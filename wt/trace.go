@@ -0,0 +1,98 @@
+package wt
+
+import (
+	"context"
+	"net/http"
+)
+
+// Tracer starts the spans of a trace. It mirrors the tracer of OpenTelemetry
+// closely enough to be implemented by a thin adapter, without the SDK
+// depending on it.
+//
+// The client starts a span for each call of a service method, with a child
+// span per file and per part uploaded, and per request sent to the API or
+// the storage. Requests are bound to the context of their span.
+type Tracer interface {
+	// Start starts a span, child of the span of ctx if any, and returns it
+	// along with a context holding it.
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is an operation in a trace, started by a Tracer.
+type Span interface {
+	// SetAttributes adds attributes to the span.
+	SetAttributes(attrs ...Attribute)
+
+	// RecordError records the error the operation failed with.
+	RecordError(err error)
+
+	// End ends the span.
+	End()
+}
+
+// Propagator is implemented by tracers that propagate the span of ctx to the
+// API and the storage in the headers of requests, such as the W3C
+// traceparent header.
+type Propagator interface {
+	Inject(ctx context.Context, h http.Header)
+}
+
+// Attribute describes a span. Value is a string, a bool, an int or an int64.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// attr returns an Attribute.
+func attr(key string, value interface{}) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// idAttr returns the attribute of the ID of a transfer or a board.
+func idAttr(bot boardOrTransfer) Attribute {
+	if _, ok := bot.(*Board); ok {
+		return attr("wt.board.id", bot.GetID())
+	}
+	return attr("wt.transfer.id", bot.GetID())
+}
+
+// span wraps a Span of the tracer of the client. A nil span does nothing, so
+// that tracing can be disabled.
+type span struct {
+	s Span
+}
+
+// startSpan starts a span with the tracer of the client, if any.
+func (c *Client) startSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, *span) {
+	if c.Tracer == nil {
+		return ctx, nil
+	}
+	ctx, s := c.Tracer.Start(ctx, name, attrs...)
+	return ctx, &span{s: s}
+}
+
+// inject propagates the span of ctx in the headers of req, if the tracer of
+// the client supports it.
+func (c *Client) inject(ctx context.Context, req *http.Request) {
+	if p, ok := c.Tracer.(Propagator); ok {
+		p.Inject(ctx, req.Header)
+	}
+}
+
+// set adds attributes to the span.
+func (s *span) set(attrs ...Attribute) {
+	if s != nil {
+		s.s.SetAttributes(attrs...)
+	}
+}
+
+// end records err, if any, and ends the span.
+func (s *span) end(err error) {
+	if s == nil {
+		return
+	}
+	if err != nil {
+		s.s.RecordError(err)
+	}
+	s.s.End()
+}
@@ -0,0 +1,167 @@
+package wt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingTracer records the spans it starts, and propagates their names.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	name   string
+	parent *recordedSpan
+	attrs  map[string]interface{}
+	err    error
+	ended  bool
+}
+
+type spanKey struct{}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	parent, _ := ctx.Value(spanKey{}).(*recordedSpan)
+	s := &recordedSpan{name: name, parent: parent, attrs: make(map[string]interface{})}
+	s.SetAttributes(attrs...)
+
+	t.mu.Lock()
+	t.spans = append(t.spans, s)
+	t.mu.Unlock()
+
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func (t *recordingTracer) Inject(ctx context.Context, h http.Header) {
+	if s, ok := ctx.Value(spanKey{}).(*recordedSpan); ok {
+		h.Set("X-Test-Span", s.name)
+	}
+}
+
+// find returns the spans with the given name.
+func (t *recordingTracer) find(name string) []*recordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var spans []*recordedSpan
+	for _, s := range t.spans {
+		if s.name == name {
+			spans = append(spans, s)
+		}
+	}
+	return spans
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *recordedSpan) RecordError(err error) { s.err = err }
+
+func (s *recordedSpan) End() { s.ended = true }
+
+// path returns the names of the span and of its ancestors, root first.
+func (s *recordedSpan) path() string {
+	if s.parent == nil {
+		return s.name
+	}
+	return s.parent.path() + " > " + s.name
+}
+
+func TestClient_tracing(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	tracer := &recordingTracer{}
+	client.Tracer = tracer
+	client.RetryPolicy = nil
+
+	var (
+		mu      sync.Mutex
+		headers = make(map[string]string)
+	)
+	record := func(r *http.Request) {
+		mu.Lock()
+		headers[r.URL.Path] = r.Header.Get("X-Test-Span")
+		mu.Unlock()
+	}
+
+	mux.HandleFunc("/transfers", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		fmt.Fprint(w, `{"id": "1", "state": "uploading", "files": [
+			{"id": "1", "name": "pony.txt", "size": 5, "multipart": {"part_numbers": 1, "chunk_size": 5}}
+		]}`)
+	})
+	mux.HandleFunc("/transfers/1/files/1/upload-url/1", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		fmt.Fprintf(w, `{"success": true, "url": "%v/part/1"}`, srvURL)
+	})
+	mux.HandleFunc("/part/1", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+	})
+	mux.HandleFunc("/transfers/1/files/1/upload-complete", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		fmt.Fprint(w, `{"id": "1"}`)
+	})
+	mux.HandleFunc("/transfers/1/finalize", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	_, err := client.Transfers.Create(context.Background(), nil, NewBuffer("pony.txt", []byte("yehaa")))
+	if err == nil {
+		t.Fatal("Transfers.Create returned no error")
+	}
+
+	wantPaths := map[string]bool{
+		"wt.Transfers.Create > wt.Transfers.CreateSession > wt.api.request":              true,
+		"wt.Transfers.Create > wt.Transfers.Resume > wt.file > wt.part > wt.api.request": true,
+		"wt.Transfers.Create > wt.Transfers.Resume > wt.file > wt.part > wt.storage.put": true,
+		"wt.Transfers.Create > wt.Transfers.Resume > wt.file > wt.api.request":           true,
+		"wt.Transfers.Create > wt.Transfers.Resume > wt.api.request":                     true,
+	}
+
+	var paths []string
+	for _, s := range tracer.spans {
+		if !s.ended {
+			t.Errorf("span %v was not ended", s.path())
+		}
+		paths = append(paths, s.path())
+		delete(wantPaths, s.path())
+	}
+	for p := range wantPaths {
+		t.Errorf("no span %v in %v", p, strings.Join(paths, ", "))
+	}
+
+	create := tracer.find("wt.Transfers.Create")
+	if len(create) != 1 || create[0].err == nil || create[0].attrs["wt.transfer.id"] != "1" {
+		t.Errorf("wt.Transfers.Create span is %+v, want one with the transfer ID and the error", create)
+	}
+
+	parts := tracer.find("wt.part")
+	if len(parts) != 1 || parts[0].attrs["wt.part.number"] != int64(1) || parts[0].attrs["wt.bytes"] != int64(5) {
+		t.Errorf("wt.part spans are %+v, want one of part 1 of 5 bytes", parts)
+	}
+
+	puts := tracer.find("wt.storage.put")
+	if len(puts) != 1 || puts[0].attrs["http.response.status_code"] != http.StatusOK {
+		t.Errorf("wt.storage.put spans are %+v, want one with a 200 status", puts)
+	}
+
+	for path, want := range map[string]string{
+		"/transfers":            "wt.api.request",
+		"/part/1":               "wt.storage.put",
+		"/transfers/1/finalize": "wt.api.request",
+	} {
+		if got := headers[path]; got != want {
+			t.Errorf("request to %v propagated span %q, want %q", path, got, want)
+		}
+	}
+}
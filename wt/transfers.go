@@ -47,6 +47,10 @@ type File struct {
 	Type      *string    `json:"type"`
 	Name      *string    `json:"name"`
 	ID        *string    `json:"id"`
+
+	// Digest records what was uploaded for the file by Create or Resume. It
+	// is not part of the API responses.
+	Digest *FileDigest `json:"-"`
 }
 
 // GetName returns the Name field if it is not nil. Otherwise, it returns
@@ -67,6 +71,14 @@ func (f *File) GetID() string {
 	return *f.ID
 }
 
+// GetSize returns the Size field if it is not nil. Otherwise, it returns 0.
+func (f *File) GetSize() int64 {
+	if f == nil || f.Size == nil {
+		return 0
+	}
+	return *f.Size
+}
+
 // GetMultipart returns the Multipart field.
 func (f *File) GetMultipart() *Multipart {
 	if f == nil || f.Multipart == nil {
@@ -135,56 +147,174 @@ type TransfersService service
 // does the whole ceremony - create a transfer request, get the S3 signed URLs,
 // actually upload the file to S3, and complete and finalize the transfer.
 //
-// Create parameter data types can be string, *os.File, *Buffer, *LocalFile.
+// Create accepts any Uploadable such as *Buffer, *LocalFile or a custom source.
 // Slices can be passed but will have to be unpacked.
-func (t *TransfersService) Create(ctx context.Context, message *string, up ...Uploadable) (*Transfer, error) {
+//
+// Create is a shorthand for CreateSession followed by Resume. Use these
+// instead if an interrupted upload must be resumable. If the client has
+// Cleanup set, the transfer is deleted when the upload fails.
+func (t *TransfersService) Create(ctx context.Context, message *string, up ...Uploadable) (transfer *Transfer, err error) {
+	ctx, span := t.client.startSpan(ctx, "wt.Transfers.Create", attr("wt.files", len(up)))
+	defer func() { span.end(err) }()
+
+	session, err := t.CreateSession(ctx, message, up...)
+	if err != nil {
+		return nil, err
+	}
+	span.set(attr("wt.transfer.id", session.Transfer.GetID()))
+
+	transfer, err = t.Resume(ctx, session, up...)
+	if err != nil {
+		t.client.cleanup(ctx, "transfer "+session.Transfer.GetID(), func(ctx context.Context) error {
+			return t.Delete(ctx, session.Transfer)
+		})
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// CreateSession creates a transfer without uploading anything yet. It returns
+// a session which records the upload progress of the transfer, to be passed
+// to Resume along with the same uploadables. Uploadables are checked with
+// Validate first, and those sharing a name are handled according to the
+// DuplicateNames policy of the client.
+func (t *TransfersService) CreateSession(ctx context.Context, message *string, up ...Uploadable) (session *TransferSession, err error) {
+	ctx, span := t.client.startSpan(ctx, "wt.Transfers.CreateSession", attr("wt.files", len(up)))
+	defer func() { span.end(err) }()
+
 	if len(up) == 0 {
 		return nil, fmt.Errorf("empty files")
 	}
 
-	// `filemap` keys are file names. We need this mapping to get the
-	// actual file or buffer easily when we receive response from the transfer
-	// request.
-	filemap := make(map[string]Uploadable)
-
-	for _, f := range up {
-		name, _ := f.Stat()
-		filemap[name] = f
+	up, err = t.client.prepareUploads(up)
+	if err != nil {
+		return nil, err
 	}
 
+	newProgressTracker(t.client.Progress, up...).setPhase(PhaseCreate, "")
+
 	// Create a transfer object. Note that this does not upload the file or buffer.
 	transfer, err := t.createTransfer(ctx, message, up...)
 	if err != nil {
 		return nil, err
 	}
+	span.set(attr("wt.transfer.id", transfer.GetID()))
+
+	return NewTransferSession(transfer), nil
+}
+
+// Resume uploads the parts of the transfer of a session that have not been
+// uploaded yet, then completes and finalizes the transfer. Uploadables must be
+// all of those the session was created with, in the same order, so that
+// duplicate names are renamed the same way. They are matched to the files of
+// the transfer by name, or by position if the API renamed some files. Those of
+// files that have been fully uploaded are not read again.
+func (t *TransfersService) Resume(ctx context.Context, session *TransferSession, up ...Uploadable) (transfer *Transfer, err error) {
+	if session == nil || session.Transfer == nil {
+		return nil, fmt.Errorf("empty transfer session")
+	}
+
+	transfer = session.Transfer
 
-	var errs []error
+	ctx, span := t.client.startSpan(ctx, "wt.Transfers.Resume", attr("wt.transfer.id", transfer.GetID()))
+	defer func() { span.end(err) }()
+
+	if len(up) != len(transfer.Files) {
+		return nil, fmt.Errorf("transfer %v has %d files, got %d uploadables",
+			transfer.GetID(), len(transfer.Files), len(up))
+	}
+
+	up, err = uniqueNames(t.client.DuplicateNames, up)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]fileItem, len(transfer.Files))
+	for i, f := range transfer.Files {
+		files[i] = f
+	}
+
+	var (
+		errs []error
+		fts  []*fileTransfer
+		ups  []Uploadable
+	)
 
 	// Once we have the files that have been acknowledged by WeTransfer, we
-	// map the files with our filemap so we begin the actual uploading.
-	for _, f := range transfer.Files {
-		name := f.GetName()
-		if tx, ok := filemap[name]; ok {
-			ft := newFileTransfer(tx, f)
-			err = t.client.uploader.upload(ctx, transfer, ft)
-			if err != nil {
-				errs = append(errs, err)
-			}
+	// pair them with our uploadables so we begin the actual uploading.
+	for i, ft := range matchFiles(up, files) {
+		f := transfer.Files[i]
+		if session.complete(f) {
+			// Uploaded already, but maybe not marked as complete.
+			fts = append(fts, newFileTransfer(nil, f))
+			continue
+		}
+
+		if ft == nil {
+			errs = append(errs, fmt.Errorf("no uploadable for file %q", f.GetName()))
+			continue
+		}
+
+		fid := f.GetID()
+		ft.uploaded = func(partNum int64) bool {
+			return session.IsUploaded(fid, partNum)
+		}
+		ft.onPart = func(partNum int64) {
+			session.markUploaded(fid, partNum)
 		}
+
+		fts = append(fts, ft)
+		ups = append(ups, ft.up)
 	}
 
-	// Do not complete and finalize the transfer if there are errors
+	// Nothing has been uploaded yet if some files cannot be.
 	if len(errs) > 0 {
 		return nil, joinErrors(errs, nil)
 	}
 
-	// Complete the transfer since there are no errors
-	_, err = t.complete(ctx, transfer)
+	progress := newProgressTracker(t.client.Progress, ups...)
+	for _, ft := range fts {
+		if ft.up != nil {
+			progress.add(ft)
+		}
+	}
+	progress.setPhase(PhaseUpload, transfer.GetID())
+
+	// Each file is marked as complete as soon as its parts are uploaded.
+	err = t.client.uploader.uploadFiles(ctx, transfer, fts, func(ctx context.Context, ft *fileTransfer) error {
+		_, err := t.completeFile(ctx, transfer, ft.file.(*File))
+		return err
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := session.err(); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Do not finalize the transfer if there are errors
+	if len(errs) > 0 {
+		return nil, joinErrors(errs, nil)
+	}
+
+	progress.setPhase(PhaseFinalize, "")
+	transfer, err = t.finalize(ctx, transfer.GetID())
 	if err != nil {
 		return nil, err
 	}
 
-	return t.finalize(ctx, transfer.GetID())
+	digests := make(map[string]*FileDigest, len(fts))
+	for _, ft := range fts {
+		digests[ft.getID()] = ft.digest
+	}
+	for _, f := range transfer.Files {
+		f.Digest = digests[f.GetID()]
+	}
+
+	progress.setPhase(PhaseDone, "")
+	return transfer, nil
 }
 
 // createTransfer returns a transfer object after submitting a new transfer
@@ -212,51 +342,34 @@ func (t *TransfersService) createTransfer(ctx context.Context, message *string,
 	if _, err = t.client.Do(ctx, req, &ts); err != nil {
 		return nil, err
 	}
+	t.client.count(MetricTransfersCreated, 1)
 
 	return &ts, nil
 }
 
-// complete informs WeTransfer that all the uploading for our files is done.
-// When the files are uploaded to S3, WeTransfer has no way of determining if
-// the transfer is successful or not. After the call to the endpoint is made,
-// this method returns the list of completed transfer responses which length is
-// equal to the number of files specified in the transfer request.
-func (t *TransfersService) complete(ctx context.Context, tx *Transfer) ([]*completedTransfer, error) {
-	completed := make([]*completedTransfer, 0)
-
-	var errs []error
-
+// completeFile informs WeTransfer that all the parts of a file are uploaded.
+func (t *TransfersService) completeFile(ctx context.Context, tx *Transfer, file *File) (*completedTransfer, error) {
 	tid := url.PathEscape(tx.GetID())
+	fid := url.PathEscape(file.GetID())
+	path := fmt.Sprintf("transfers/%v/files/%v/upload-complete", tid, fid)
 
-	for _, file := range tx.Files {
-		fid := url.PathEscape(file.GetID())
-		path := fmt.Sprintf("transfers/%v/files/%v/upload-complete", tid, fid)
-		partNum := file.Multipart.GetPartNumbers()
-		req, err := t.client.NewRequest("PUT", path, &struct {
-			PartNumbers int64 `json:"part_numbers"`
-		}{
-			PartNumbers: partNum,
-		})
-		if err != nil {
-			errs = append(errs, err)
-			continue
-		}
-		var ct completedTransfer
-		if _, err = t.client.Do(ctx, req, &ct); err != nil {
-			errs = append(errs, err)
-		}
-		completed = append(completed, &ct)
+	req, err := t.client.NewRequest("PUT", path, &struct {
+		PartNumbers int64 `json:"part_numbers"`
+	}{
+		PartNumbers: file.Multipart.GetPartNumbers(),
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if len(errs) > 0 {
-		errmsg := fmt.Sprintf("completing transfer %v, with %v error(s)", tx.GetID(), len(errs))
-		return nil, joinErrors(errs, &errmsg)
+	var ct completedTransfer
+	if _, err = t.client.Do(ctx, req, &ct); err != nil {
+		return nil, err
 	}
 
-	return completed, nil
+	return &ct, nil
 }
 
-// Finalize closes a transfer for modification rendering it immutable and downloadable.
 func (t *TransfersService) finalize(ctx context.Context, id string) (*Transfer, error) {
 	path := fmt.Sprintf("transfers/%v/finalize", url.PathEscape(id))
 
@@ -269,12 +382,16 @@ func (t *TransfersService) finalize(ctx context.Context, id string) (*Transfer,
 	if _, err = t.client.Do(ctx, req, transfer); err != nil {
 		return nil, err
 	}
+	t.client.count(MetricTransfersFinalized, 1)
 
 	return transfer, nil
 }
 
 // Find retrieves the transfer object given an ID.
-func (t *TransfersService) Find(ctx context.Context, id string) (*Transfer, error) {
+func (t *TransfersService) Find(ctx context.Context, id string) (transfer *Transfer, err error) {
+	ctx, span := t.client.startSpan(ctx, "wt.Transfers.Find", attr("wt.transfer.id", id))
+	defer func() { span.end(err) }()
+
 	path := fmt.Sprintf("transfers/%v", url.PathEscape(id))
 
 	req, err := t.client.NewRequest("GET", path, nil)
@@ -282,10 +399,28 @@ func (t *TransfersService) Find(ctx context.Context, id string) (*Transfer, erro
 		return nil, err
 	}
 
-	transfer := &Transfer{}
+	transfer = &Transfer{}
 	if _, err = t.client.Do(ctx, req, transfer); err != nil {
 		return nil, err
 	}
 
 	return transfer, nil
 }
+
+// Delete removes a transfer along with its files. It is mostly useful for
+// transfers which have not been finalized, such as those of abandoned
+// sessions.
+func (t *TransfersService) Delete(ctx context.Context, transfer *Transfer) (err error) {
+	ctx, span := t.client.startSpan(ctx, "wt.Transfers.Delete", attr("wt.transfer.id", transfer.GetID()))
+	defer func() { span.end(err) }()
+
+	path := fmt.Sprintf("transfers/%v", url.PathEscape(transfer.GetID()))
+
+	req, err := t.client.NewRequest("DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.client.Do(ctx, req, nil)
+	return err
+}
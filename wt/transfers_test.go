@@ -105,7 +105,64 @@ func TestTransfersService_Create(t *testing.T) {
 	}
 }
 
-func TestTransfersService_complete(t *testing.T) {
+func TestTransfersService_Create_cleanup(t *testing.T) {
+	for _, cleanup := range []bool{false, true} {
+		client, mux, srvURL, teardown := setup()
+
+		client.RetryPolicy = nil
+		client.Cleanup = cleanup
+
+		mux.HandleFunc("/transfers", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"id": "1", "state": "uploading", "files": [
+				{"id": "1", "name": "pony.txt", "size": 5, "multipart": {"part_numbers": 1, "chunk_size": 5}}
+			]}`)
+		})
+		mux.HandleFunc("/transfers/1/files/1/upload-url/1", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"success": true, "url": "%v/part/1"}`, srvURL)
+		})
+		mux.HandleFunc("/part/1", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+
+		var deleted bool
+		mux.HandleFunc("/transfers/1", func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, "DELETE")
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		_, err := client.Transfers.Create(context.Background(), nil, NewBuffer("pony.txt", []byte("yehaa")))
+		if err == nil {
+			t.Errorf("TransfersService.Create returned no error")
+		}
+		if deleted != cleanup {
+			t.Errorf("TransfersService.Create with cleanup %v deleted the transfer: %v", cleanup, deleted)
+		}
+
+		teardown()
+	}
+}
+
+func TestTransfersService_Delete(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	var called bool
+	mux.HandleFunc("/transfers/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		called = true
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if err := client.Transfers.Delete(context.Background(), &Transfer{ID: String("1")}); err != nil {
+		t.Errorf("TransfersService.Delete returned an error: %v", err)
+	}
+	if !called {
+		t.Error("TransfersService.Delete did not send a request")
+	}
+}
+
+func TestTransfersService_completeFile(t *testing.T) {
 	client, mux, _, teardown := setup()
 	defer teardown()
 
@@ -153,17 +210,19 @@ func TestTransfersService_complete(t *testing.T) {
 		},
 	}
 
-	completed, err := client.Transfers.complete(context.Background(), tx)
-	if err != nil {
-		t.Errorf("TransfersService.complete returned an error: %v", err)
-	}
+	for i, f := range tx.Files {
+		completed, err := client.Transfers.completeFile(context.Background(), tx, f)
+		if err != nil {
+			t.Errorf("TransfersService.completeFile returned an error: %v", err)
+		}
 
-	if !reflect.DeepEqual(completed, want) {
-		t.Errorf("TransfersService.complete returned %v, want %v", completed, want)
+		if !reflect.DeepEqual(completed, want[i]) {
+			t.Errorf("TransfersService.completeFile returned %v, want %v", completed, want[i])
+		}
 	}
 }
 
-func TestTransfersService_complete_expectationFailed(t *testing.T) {
+func TestTransfersService_completeFile_expectationFailed(t *testing.T) {
 	client, mux, _, teardown := setup()
 	defer teardown()
 
@@ -186,13 +245,13 @@ func TestTransfersService_complete_expectationFailed(t *testing.T) {
 		},
 	}
 
-	completed, err := client.Transfers.complete(context.Background(), tx)
+	completed, err := client.Transfers.completeFile(context.Background(), tx, tx.Files[0])
 	if err == nil {
 		t.Errorf("Expected error to be returned")
 	}
 
-	if len(completed) != 0 {
-		t.Errorf("Expected length to be 0")
+	if completed != nil {
+		t.Errorf("Expected no completed transfer")
 	}
 }
 
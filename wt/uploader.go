@@ -3,10 +3,19 @@ package wt
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"sort"
+	"sync"
+	"time"
 )
 
 // boardOrTransfer describes either a Transfer or a Board object
@@ -29,7 +38,12 @@ func (u *UploadURL) GetURL() string {
 	return *u.URL
 }
 
+// String returns u with the query of its URL, which holds its signature,
+// redacted.
 func (u UploadURL) String() string {
+	if u.URL != nil {
+		u.URL = String(redactURLString(*u.URL))
+	}
 	return ToString(u)
 }
 
@@ -37,54 +51,218 @@ func (u UploadURL) String() string {
 // and boards
 type uploaderService service
 
-// upload attempts to upload a file or a buffer. It does so by using the response
+// upload attempts to upload an uploadable. It does so by using the response
 // from the transfer request which has the multipart info. This info is used to
-// upload the file or buffer in chunks if needed.
+// upload the content of the uploadable in chunks if needed.
 func (u *uploaderService) upload(ctx context.Context, bot boardOrTransfer, ft *fileTransfer) error {
 	fid := ft.getID()
 	mid, partNum, chunkSize := ft.stat()
 
-	reader, file, err := ft.reader()
+	rc, err := ft.open()
 	if err != nil {
 		return err
 	}
-	if file != nil {
-		defer file.Close()
+	defer rc.Close()
+
+	name, size := ft.up.Stat()
+	parts := newPartReader(rc, size, chunkSize)
+
+	digest := &FileDigest{
+		FileID: fid,
+		Name:   name,
+		Size:   size,
+		Parts:  make([]*PartDigest, partNum),
+	}
+
+	// The whole file can only be hashed if all of its parts are read.
+	var whole *fileDigester
+	if !ft.skipsAny(partNum) {
+		whole = newFileDigester(partNum)
 	}
 
-	var errs []error
+	var (
+		errs []error
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+	)
 
-	buf := make([]byte, 0, chunkSize)
-	errChan := make(chan error, partNum)
+	addErr := func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+
+	// Parts of this file are bounded by sem, and parts across all files by
+	// the shared chunk pool. A part holds its buffer until its upload is done.
+	// Parts sliced from memory need no buffer, but still take one of the pool.
+	pool := u.client.chunkPool()
+	sem := make(chan struct{}, maxInt(u.client.FileConcurrency, 1))
+
+	bufSize := chunkSize
+	if parts.inMemory() {
+		bufSize = 0
+	}
 
 	for i := int64(1); i <= partNum; i++ {
-		n, err := reader.Read(buf[:chunkSize])
-		if err != nil && err != io.EOF {
-			errs = append(errs, err)
+		if ft.skip(i) {
+			// Already uploaded, move on to the next part.
+			ft.progress.skip(ft, i, parts.length(i))
+			continue
+		}
+
+		if err := acquire(ctx, sem); err != nil {
+			addErr(err)
 			break
 		}
-		buf = buf[:n]
-		bufCopy := make([]byte, n)
-		copy(bufCopy, buf) // copy bytes because.. goroutine.
-		go func(i int64, data []byte) {
-			uurl, err := u.getUploadURL(ctx, bot, fid, i, mid)
+		buf, err := pool.get(ctx, bufSize)
+		if err != nil {
+			<-sem
+			addErr(err)
+			break
+		}
+
+		// Parts which cannot be read at an offset are read in order, before
+		// moving on to the next one. Others are read concurrently.
+		var data []byte
+		if !parts.random() {
+			data, err = parts.read(i, buf)
+			if err == nil {
+				err = parts.check(i, data)
+			}
+			if err == nil && i == partNum {
+				// Content past the size would be lost, fail before the
+				// last part is sent.
+				err = parts.checkEnd()
+			}
+			if err != nil {
+				pool.put(buf)
+				<-sem
+				addErr(&UploadPartError{FileID: fid, PartNumber: i, Err: err})
+				break
+			}
+		}
+
+		wg.Add(1)
+		go func(i int64, buf, data []byte) {
+			ctx, span := u.client.startSpan(ctx, "wt.part",
+				idAttr(bot),
+				attr("wt.file.id", fid),
+				attr("wt.part.number", i),
+				attr("wt.bytes", parts.length(i)))
+
+			var err error
+			defer func() {
+				span.end(err)
+				pool.put(buf)
+				<-sem
+				wg.Done()
+			}()
+
+			if parts.random() {
+				data, err = parts.read(i, buf)
+				if err == nil {
+					err = parts.check(i, data)
+				}
+				if err != nil {
+					whole.add(ctx, i, nil, false)
+					addErr(&UploadPartError{FileID: fid, PartNumber: i, Err: err})
+					return
+				}
+			}
+			whole.add(ctx, i, data, true)
+
+			var pd *PartDigest
+			pd, err = u.uploadPart(ctx, bot, fid, i, mid, data)
 			if err != nil {
-				errChan <- err
-			} else {
-				errChan <- uploadBytes(ctx, uurl, data)
+				addErr(&UploadPartError{FileID: fid, PartNumber: i, Err: err})
+				return
 			}
-		}(i, bufCopy)
+			digest.Parts[i-1] = pd
+			ft.partDone(i)
+			ft.progress.part(ft, i, int64(len(data)))
+		}(i, buf, data)
 	}
 
-	for i := int64(0); i < partNum; i++ {
-		err := <-errChan
-		if err != nil {
-			errs = append(errs, err)
+	wg.Wait()
+
+	if len(errs) > 0 {
+		errmsg := fmt.Sprintf("upload %v failed with %v error(s)", bot.GetID(), len(errs))
+		return joinErrors(errs, &errmsg)
+	}
+
+	digest.MD5, digest.SHA256 = whole.sum()
+	ft.digest = digest
+
+	return nil
+}
+
+// uploadFiles uploads files in parallel, and marks each of them as complete
+// with the complete function as soon as its own parts are uploaded. At most
+// Concurrency files are uploaded at the same time, their parts sharing the
+// chunk pool. Files whose fileTransfer has no uploadable are only completed.
+// Errors are gathered per file, as *FileError.
+func (u *uploaderService) uploadFiles(ctx context.Context, bot boardOrTransfer, fts []*fileTransfer, complete func(context.Context, *fileTransfer) error) error {
+	var (
+		errs []error
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+	)
+
+	sem := make(chan struct{}, maxInt(u.client.Concurrency, 1))
+
+	for i, ft := range fts {
+		if err := acquire(ctx, sem); err != nil {
+			// Files left are not even started.
+			mu.Lock()
+			for _, ft := range fts[i:] {
+				errs = append(errs, &FileError{FileID: ft.getID(), FileName: ft.getName(), Err: err})
+			}
+			mu.Unlock()
+			break
 		}
+		wg.Add(1)
+		go func(ft *fileTransfer) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			ctx, span := u.client.startSpan(ctx, "wt.file",
+				idAttr(bot),
+				attr("wt.file.id", ft.getID()),
+				attr("wt.file.name", ft.getName()))
+
+			var err error
+			if ft.up != nil {
+				_, size := ft.up.Stat()
+				span.set(attr("wt.bytes", size))
+				err = u.upload(ctx, bot, ft)
+			}
+			if err == nil {
+				err = complete(ctx, ft)
+			}
+			span.end(err)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, &FileError{FileID: ft.getID(), FileName: ft.getName(), Err: err})
+				mu.Unlock()
+			}
+		}(ft)
 	}
 
+	wg.Wait()
+
 	if len(errs) > 0 {
-		errmsg := fmt.Sprintf("upload %v failed with %v error(s)", bot.GetID(), len(errs))
+		// Report files in the order they were given.
+		order := make(map[string]int, len(fts))
+		for i, ft := range fts {
+			order[ft.getID()] = i
+		}
+		sort.Slice(errs, func(i, j int) bool {
+			return order[errs[i].(*FileError).FileID] < order[errs[j].(*FileError).FileID]
+		})
+
+		errmsg := fmt.Sprintf("upload %v failed for %v file(s)", bot.GetID(), len(errs))
 		return joinErrors(errs, &errmsg)
 	}
 
@@ -121,40 +299,153 @@ func (u *uploaderService) getUploadURL(ctx context.Context, bot boardOrTransfer,
 	return &uurl, nil
 }
 
-func uploadBytes(ctx context.Context, uurl *UploadURL, b []byte) error {
+// uploadPart uploads a single part of a file to the storage. Failed uploads are
+// retried according to the retry policy of the client, and a fresh upload URL
+// is requested when the presigned one has expired between attempts.
+func (u *uploaderService) uploadPart(ctx context.Context, bot boardOrTransfer, fid string, partNum int64, mid string, data []byte) (*PartDigest, error) {
+	policy := u.client.RetryPolicy
+
+	sum := md5.Sum(data)
+	digest := &PartDigest{
+		PartNumber: partNum,
+		Size:       int64(len(data)),
+		MD5:        hex.EncodeToString(sum[:]),
+	}
+
+	var uurl *UploadURL
+
+	for attempt := 1; ; attempt++ {
+		var err error
+		if uurl == nil {
+			uurl, err = u.getUploadURL(ctx, bot, fid, partNum, mid)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		digest.ETag, err = u.uploadBytes(ctx, uurl, data, sum[:], attempt, "file_id", fid, "part", partNum)
+		if err == nil {
+			return digest, nil
+		}
+
+		var resp *http.Response
+		var serr *StorageError
+		if errors.As(err, &serr) {
+			resp = serr.Response
+		}
+
+		var cerr *ChecksumError
+		if resp != nil && resp.StatusCode == http.StatusForbidden {
+			// Presigned URLs are rejected with a 403 once they expire.
+			uurl = nil
+			if attempt >= policy.maxAttempts() || ctx.Err() != nil {
+				return nil, err
+			}
+		} else if errors.As(err, &cerr) {
+			// The part got corrupted on its way, send it again.
+			if attempt >= policy.maxAttempts() || ctx.Err() != nil {
+				return nil, err
+			}
+		} else if !policy.shouldRetry(ctx, attempt, resp, err) {
+			return nil, err
+		}
+
+		if err := policy.wait(ctx, attempt, resp); err != nil {
+			return nil, err
+		}
+		u.client.retried("part_upload")
+		u.client.log(ctx, slog.LevelDebug, "retrying part upload",
+			"file_id", fid, "part", partNum, "attempt", attempt+1, "error", err)
+	}
+}
+
+// uploadBytes uploads data to a presigned upload URL of the storage, along
+// with its MD5 sum, and logs the upload with args. It returns the ETag of the
+// uploaded data, if any, and a *ChecksumError if the storage did not get the
+// data that was sent.
+func (u *uploaderService) uploadBytes(ctx context.Context, uurl *UploadURL, b []byte, sum []byte, attempt int, args ...interface{}) (etag string, err error) {
 	url := uurl.GetURL()
 
 	if url == "" {
-		return fmt.Errorf("blank URL")
+		return "", fmt.Errorf("blank URL")
 	}
 
 	if len(b) == 0 {
-		return fmt.Errorf("blank data for URL: %v", uurl)
+		return "", fmt.Errorf("blank data for upload URL")
 	}
 
-	reader := bytes.NewReader(b)
-	req, err := http.NewRequest("PUT", url, reader)
+	ctx, span := u.client.startSpan(ctx, "wt.storage.put",
+		attr("wt.attempt", attempt),
+		attr("wt.bytes", len(b)))
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewReader(b))
 	if err != nil {
-		return err
+		err = redactError(err)
+		span.end(err)
+		return "", err
 	}
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum))
+	span.set(attr("url.path", req.URL.Path))
+	u.client.inject(ctx, req)
+
+	var r *http.Response
+	start := time.Now()
+	defer func() {
+		args = append(args, "bytes", len(b))
+		u.client.logRequest(ctx, "part upload", req, r, start, attempt, err, args...)
+
+		u.client.count(MetricPartUploads, 1, statusLabel(r))
+		u.client.observe(MetricPartUploadDuration, seconds(start), statusLabel(r))
+		if err == nil {
+			u.client.count(MetricPartUploadBytes, float64(len(b)))
+		}
+
+		if r != nil {
+			span.set(attr("http.response.status_code", r.StatusCode))
+		}
+		span.end(err)
+	}()
 
-	r, err := http.DefaultClient.Do(req)
+	r, err = u.client.storage.Do(req)
 	if err != nil {
 		// If we got an error, and the context has been canceled,
 		// the context's error is probably more useful.
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return "", ctx.Err()
 		default:
 		}
-		return err
+		return "", redactError(err)
 	}
 	defer r.Body.Close()
 
 	if c := r.StatusCode; 200 <= c && c <= 299 {
+		return r.Header.Get("ETag"), nil
+	}
+
+	if r.StatusCode == http.StatusBadRequest {
+		body, _ := ioutil.ReadAll(io.LimitReader(r.Body, maxErrorBody))
+		if bytes.Contains(body, []byte("<Code>BadDigest</Code>")) {
+			return "", &ChecksumError{Want: hex.EncodeToString(sum)}
+		}
+	}
+
+	return "", &StorageError{Response: r}
+}
+
+// acquire takes a slot of sem, unless ctx is done first.
+func acquire(ctx context.Context, sem chan struct{}) error {
+	select {
+	case sem <- struct{}{}:
 		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
+}
 
-	return fmt.Errorf("upload bytes error %v %v: %d",
-		r.Request.Method, r.Request.URL, r.StatusCode)
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
 }
@@ -2,10 +2,23 @@ package wt
 
 import (
 	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
+	"time"
 )
 
 func TestUploaderService_upload(t *testing.T) {
@@ -70,6 +83,333 @@ func TestUploaderService_upload(t *testing.T) {
 	}
 }
 
+// blob is a custom Uploadable that is neither a *Buffer nor a *LocalFile.
+type blob struct {
+	name string
+	data string
+}
+
+func (b *blob) Stat() (string, int64) { return b.name, int64(len(b.data)) }
+
+func (b *blob) Open() (io.ReadCloser, error) {
+	return ioutil.NopCloser(strings.NewReader(b.data)), nil
+}
+
+func TestUploaderService_upload_customUploadable(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	var got []byte
+
+	mux.HandleFunc("/transfers/1/files/1/upload-url/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"success": true, "url": "%v/part/1"}`, srvURL)
+	})
+	mux.HandleFunc("/part/1", func(w http.ResponseWriter, r *http.Request) {
+		got, _ = ioutil.ReadAll(r.Body)
+	})
+
+	file := &File{
+		ID:   String("1"),
+		Name: String("blob.txt"),
+		Multipart: &Multipart{
+			PartNumbers: Int64(1),
+			ChunkSize:   Int64(64),
+		},
+	}
+
+	up := &blob{name: "blob.txt", data: "from the database"}
+	ft := newFileTransfer(up, file)
+	err := client.uploader.upload(context.Background(), &Transfer{ID: String("1")}, ft)
+	if err != nil {
+		t.Errorf("upload returned an error: %v", err)
+	}
+
+	if string(got) != up.data {
+		t.Errorf("upload sent %q, want %q", got, up.data)
+	}
+}
+
+// trickle is a custom Uploadable whose reader returns a byte at a time.
+type trickle struct {
+	blob
+}
+
+func (b *trickle) Open() (io.ReadCloser, error) {
+	return ioutil.NopCloser(iotest.OneByteReader(strings.NewReader(b.data))), nil
+}
+
+func TestUploaderService_upload_exactParts(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	const content = "0123456789"
+
+	path := filepath.Join(t.TempDir(), "pony.txt")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	local, err := NewLocalFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu  sync.Mutex
+		got map[string]string
+	)
+
+	for i := 1; i <= 3; i++ {
+		part := fmt.Sprint(i)
+		mux.HandleFunc("/transfers/1/files/1/upload-url/"+part, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"success": true, "url": "%v/part/%v"}`, srvURL, part)
+		})
+		mux.HandleFunc("/part/"+part, func(w http.ResponseWriter, r *http.Request) {
+			b, _ := ioutil.ReadAll(r.Body)
+			mu.Lock()
+			got[part] = string(b)
+			mu.Unlock()
+		})
+	}
+
+	file := &File{
+		ID:        String("1"),
+		Name:      String("pony.txt"),
+		Multipart: &Multipart{PartNumbers: Int64(3), ChunkSize: Int64(4)},
+	}
+	want := map[string]string{"1": "0123", "2": "4567", "3": "89"}
+	sum := sha256.Sum256([]byte(content))
+	wantSHA := hex.EncodeToString(sum[:])
+
+	for _, up := range []Uploadable{
+		NewBuffer("pony.txt", []byte(content)),
+		local,
+		&trickle{blob{name: "pony.txt", data: content}},
+	} {
+		got = make(map[string]string)
+
+		ft := newFileTransfer(up, file)
+		err := client.uploader.upload(context.Background(), &Transfer{ID: String("1")}, ft)
+		if err != nil {
+			t.Errorf("upload of %T returned an error: %v", up, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("upload of %T sent parts %v, want %v", up, got, want)
+		}
+		if ft.digest == nil || ft.digest.SHA256 != wantSHA {
+			t.Errorf("upload of %T returned digest %+v, want SHA-256 %v", up, ft.digest, wantSHA)
+		}
+	}
+}
+
+func TestUploaderService_upload_shortContent(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	var puts int
+	mux.HandleFunc("/transfers/1/files/1/upload-url/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"success": true, "url": "%v/part"}`, srvURL)
+	})
+	mux.HandleFunc("/part", func(w http.ResponseWriter, r *http.Request) {
+		puts++
+	})
+
+	file := &File{
+		ID:        String("1"),
+		Name:      String("pony.txt"),
+		Multipart: &Multipart{PartNumbers: Int64(3), ChunkSize: Int64(4)},
+	}
+
+	// Announced as 10 bytes long, but only 6 are read.
+	up := &trickle{blob{name: "pony.txt", data: "012345"}}
+	ft := newFileTransfer(&sizedBlob{up, 10}, file)
+
+	err := client.uploader.upload(context.Background(), &Transfer{ID: String("1")}, ft)
+
+	var perr *UploadPartError
+	if !errors.As(err, &perr) || perr.PartNumber != 2 {
+		t.Errorf("upload returned %v, want an *UploadPartError of part 2", err)
+	}
+	if puts != 1 {
+		t.Errorf("upload sent %v parts, want 1", puts)
+	}
+}
+
+// sizedBlob reports a size which may not be the one of its uploadable.
+type sizedBlob struct {
+	Uploadable
+	size int64
+}
+
+func (b *sizedBlob) Stat() (string, int64) {
+	name, _ := b.Uploadable.Stat()
+	return name, b.size
+}
+
+func TestUploaderService_upload_concurrency(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	client.Concurrency = 2
+	client.FileConcurrency = 2
+
+	partNumbers := 6
+
+	var mu sync.Mutex
+	inflight, maxInflight := 0, 0
+
+	for i := 1; i <= partNumbers; i++ {
+		mux.HandleFunc(fmt.Sprintf("/transfers/1/files/1/upload-url/%v", i), func(i int) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"success": true, "url": "%v/part/%v"}`, srvURL, i)
+			}
+		}(i))
+		mux.HandleFunc(fmt.Sprintf("/part/%v", i), func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			inflight++
+			if inflight > maxInflight {
+				maxInflight = inflight
+			}
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			inflight--
+			mu.Unlock()
+		})
+	}
+
+	file := &File{
+		ID:   String("1"),
+		Name: String("pony.txt"),
+		Multipart: &Multipart{
+			PartNumbers: Int64(int64(partNumbers)),
+			ChunkSize:   Int64(4),
+		},
+	}
+
+	buf := NewBuffer("pony.txt", []byte(strings.Repeat("x", partNumbers*4)))
+	err := client.uploader.upload(context.Background(), &Transfer{ID: String("1")}, newFileTransfer(buf, file))
+	if err != nil {
+		t.Errorf("upload returned an error: %v", err)
+	}
+
+	if maxInflight > client.Concurrency {
+		t.Errorf("upload sent %v parts at the same time, want at most %v", maxInflight, client.Concurrency)
+	}
+}
+
+func TestUploaderService_uploadFiles(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	client.Concurrency = 4
+	client.RetryPolicy = nil
+
+	// The part of file 2 is only accepted once file 1 has been completed,
+	// which requires files to be uploaded in parallel and completed as soon
+	// as their own parts are.
+	completed1 := make(chan struct{})
+
+	var fts []*fileTransfer
+	for i := 1; i <= 3; i++ {
+		id := fmt.Sprint(i)
+		mux.HandleFunc("/transfers/1/files/"+id+"/upload-url/1", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"success": true, "url": "%v/part/%v"}`, srvURL, id)
+		})
+
+		file := &File{
+			ID:        String(id),
+			Name:      String("file" + id),
+			Multipart: &Multipart{PartNumbers: Int64(1), ChunkSize: Int64(1)},
+		}
+		fts = append(fts, newFileTransfer(NewBuffer("file"+id, []byte("x")), file))
+	}
+
+	mux.HandleFunc("/part/1", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/part/2", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-completed1:
+		case <-time.After(time.Second):
+			t.Error("file 1 was not completed while file 2 was uploading")
+		}
+	})
+	mux.HandleFunc("/part/3", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	// Already uploaded, only completed.
+	fts = append(fts, newFileTransfer(nil, &File{ID: String("4"), Name: String("file4")}))
+
+	var (
+		mu        sync.Mutex
+		completed []string
+	)
+	err := client.uploader.uploadFiles(context.Background(), &Transfer{ID: String("1")}, fts, func(ctx context.Context, ft *fileTransfer) error {
+		mu.Lock()
+		completed = append(completed, ft.getID())
+		mu.Unlock()
+		if ft.getID() == "1" {
+			close(completed1)
+		}
+		return nil
+	})
+
+	var merr *MultiError
+	if !errors.As(err, &merr) || len(merr.Errors) != 1 {
+		t.Fatalf("uploadFiles returned %v, want a *MultiError of 1 error", err)
+	}
+
+	var ferr *FileError
+	if !errors.As(merr.Errors[0], &ferr) || ferr.FileID != "3" || ferr.FileName != "file3" {
+		t.Errorf("uploadFiles returned %v, want a *FileError of file 3", merr.Errors[0])
+	}
+
+	var perr *UploadPartError
+	if !errors.As(err, &perr) || perr.PartNumber != 1 {
+		t.Errorf("uploadFiles returned %v, want an *UploadPartError of part 1", err)
+	}
+
+	sort.Strings(completed)
+	if want := []string{"1", "2", "4"}; !reflect.DeepEqual(completed, want) {
+		t.Errorf("uploadFiles completed %v, want %v", completed, want)
+	}
+}
+
+func TestUploaderService_uploadFiles_canceled(t *testing.T) {
+	client, _, _, teardown := setup()
+	defer teardown()
+
+	client.Concurrency = 1
+
+	var fts []*fileTransfer
+	for i := 1; i <= 2; i++ {
+		id := fmt.Sprint(i)
+		file := &File{
+			ID:        String(id),
+			Name:      String("file" + id),
+			Multipart: &Multipart{PartNumbers: Int64(1), ChunkSize: Int64(1)},
+		}
+		fts = append(fts, newFileTransfer(NewBuffer("file"+id, []byte("x")), file))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := client.uploader.uploadFiles(ctx, &Transfer{ID: String("1")}, fts, func(ctx context.Context, ft *fileTransfer) error {
+		t.Errorf("uploadFiles completed file %v", ft.getID())
+		return nil
+	})
+
+	var merr *MultiError
+	if !errors.As(err, &merr) || len(merr.Errors) != 2 {
+		t.Fatalf("uploadFiles returned %v, want a *MultiError of 2 errors", err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("uploadFiles returned %v, want %v", err, context.Canceled)
+	}
+}
+
 func TestUploaderService_getUploadURL(t *testing.T) {
 	client, mux, _, teardown := setup()
 	defer teardown()
@@ -115,12 +455,18 @@ func TestUploaderService_getUploadURL(t *testing.T) {
 }
 
 func TestUploadBytes(t *testing.T) {
-	_, s3, s3url, teardown := setup()
+	client, s3, s3url, teardown := setup()
 	defer teardown()
 
 	s3path := "/p/1"
 
+	data := []byte("pony data")
+	sum := md5.Sum(data)
+	etag := fmt.Sprintf("%q", hex.EncodeToString(sum[:]))
+
 	s3.HandleFunc(s3path, func(w http.ResponseWriter, r *http.Request) {
+		testHeader(t, r, "Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+		w.Header().Set("ETag", etag)
 		w.WriteHeader(200)
 	})
 
@@ -129,14 +475,62 @@ func TestUploadBytes(t *testing.T) {
 		URL:     String(s3url + s3path),
 	}
 
-	err := uploadBytes(context.Background(), uurl, []byte("pony data"))
+	got, err := client.uploader.uploadBytes(context.Background(), uurl, data, sum[:], 1)
 	if err != nil {
 		t.Errorf("uploadBytes returned an error: %v", err)
 	}
+	if got != etag {
+		t.Errorf("uploadBytes returned ETag %v, want %v", got, etag)
+	}
+}
+
+func TestUploadBytes_checksumMismatch(t *testing.T) {
+	client, s3, s3url, teardown := setup()
+	defer teardown()
+
+	data := []byte("pony data")
+	sum := md5.Sum(data)
+
+	s3.HandleFunc("/bad-digest", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `<Error><Code>BadDigest</Code><Message>The Content-MD5 you specified did not match what we received.</Message></Error>`)
+	})
+
+	uurl := &UploadURL{URL: String(s3url + "/bad-digest")}
+	_, err := client.uploader.uploadBytes(context.Background(), uurl, data, sum[:], 1)
+
+	var cerr *ChecksumError
+	if !errors.As(err, &cerr) || cerr.Want != hex.EncodeToString(sum[:]) {
+		t.Errorf("uploadBytes returned %v, want a *ChecksumError", err)
+	}
+}
+
+func TestUploadBytes_encryptedETag(t *testing.T) {
+	client, s3, s3url, teardown := setup()
+	defer teardown()
+
+	data := []byte("pony data")
+	sum := md5.Sum(data)
+
+	// Buckets encrypted with SSE-KMS or SSE-C return ETags which look like
+	// an MD5 but are not the MD5 of the content.
+	etag := `"7d793037a0760186574b0282f2f435e7"`
+	s3.HandleFunc("/encrypted", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+	})
+
+	uurl := &UploadURL{URL: String(s3url + "/encrypted")}
+	got, err := client.uploader.uploadBytes(context.Background(), uurl, data, sum[:], 1)
+	if err != nil {
+		t.Errorf("uploadBytes returned an error: %v", err)
+	}
+	if got != etag {
+		t.Errorf("uploadBytes returned ETag %v, want %v", got, etag)
+	}
 }
 
 func TestUploadBytes_noSuchKey(t *testing.T) {
-	_, s3, s3url, teardown := setup()
+	client, s3, s3url, teardown := setup()
 	defer teardown()
 
 	s3.HandleFunc("/not/found/file/1", func(w http.ResponseWriter, r *http.Request) {
@@ -157,7 +551,9 @@ func TestUploadBytes_noSuchKey(t *testing.T) {
 		URL:     String(s3url + "/not/found/file/1"),
 	}
 
-	err := uploadBytes(context.Background(), uurl, []byte("pony data"))
+	data := []byte("pony data")
+	sum := md5.Sum(data)
+	_, err := client.uploader.uploadBytes(context.Background(), uurl, data, sum[:], 1)
 
 	if err == nil {
 		t.Errorf("Expected error to be returned")
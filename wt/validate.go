@@ -0,0 +1,201 @@
+package wt
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Limits are the limits of a transfer checked before it is created. A zero
+// value disables the corresponding check.
+type Limits struct {
+	MaxSize       int64 // maximum size of all files, in bytes
+	MaxFiles      int   // maximum number of files
+	MaxNameLength int   // maximum length of a file name, in characters
+}
+
+// DefaultLimits returns the limits of the public WeTransfer API: transfers of
+// up to 2 GB, and file names of up to 255 characters.
+func DefaultLimits() *Limits {
+	return &Limits{
+		MaxSize:       2 << 30,
+		MaxNameLength: 255,
+	}
+}
+
+// IssueKind identifies a problem found by Validate.
+type IssueKind string
+
+// Problems found by Validate.
+const (
+	IssueTotalSize     IssueKind = "total-size"     // the files are too large altogether
+	IssueFileCount     IssueKind = "file-count"     // there are too many files
+	IssueEmptyFile     IssueKind = "empty-file"     // a file has no content
+	IssueNameLength    IssueKind = "name-length"    // a file name is too long
+	IssueSanitizedName IssueKind = "sanitized-name" // a file name has characters the API removes
+	IssueDuplicateName IssueKind = "duplicate-name" // a file name is used more than once
+)
+
+// Issue is a problem found by Validate.
+type Issue struct {
+	Kind IssueKind
+
+	// Index and Name of the uploadable the issue is about. Index is -1 for
+	// issues about the whole transfer.
+	Index int
+	Name  string
+
+	// Warning is set for issues that do not prevent the transfer, such as
+	// names that will be changed.
+	Warning bool
+
+	Message string
+
+	err error // underlying error, if any
+}
+
+func (i *Issue) Error() string {
+	if i.Warning {
+		return "warning: " + i.Message
+	}
+	return i.Message
+}
+
+// Unwrap returns the underlying error of the issue, such as a
+// *DuplicateNameError, or nil.
+func (i *Issue) Unwrap() error { return i.err }
+
+// ValidationReport is the outcome of Validate.
+type ValidationReport struct {
+	Files     int   // number of uploadables
+	TotalSize int64 // size of all uploadables, in bytes
+	Issues    []*Issue
+}
+
+// Errors returns the issues preventing the transfer.
+func (r *ValidationReport) Errors() []*Issue {
+	return r.filter(false)
+}
+
+// Warnings returns the issues that do not prevent the transfer.
+func (r *ValidationReport) Warnings() []*Issue {
+	return r.filter(true)
+}
+
+func (r *ValidationReport) filter(warning bool) []*Issue {
+	var issues []*Issue
+	for _, i := range r.Issues {
+		if i.Warning == warning {
+			issues = append(issues, i)
+		}
+	}
+	return issues
+}
+
+// Err returns a *ValidationError if some issues prevent the transfer, or nil.
+// errors.Is and errors.As look into each of the issues.
+func (r *ValidationReport) Err() error {
+	if len(r.Errors()) == 0 {
+		return nil
+	}
+	return &ValidationError{Report: r}
+}
+
+// ValidationError occurs when uploadables cannot be transferred.
+type ValidationError struct {
+	Report *ValidationReport
+}
+
+// Unwrap returns the issues preventing the transfer.
+func (e *ValidationError) Unwrap() []error {
+	var errs []error
+	for _, i := range e.Report.Errors() {
+		errs = append(errs, i)
+	}
+	return errs
+}
+
+func (e *ValidationError) Error() string {
+	errs := e.Report.Errors()
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "validation failed with %v error(s):\n", len(errs))
+	for _, i := range errs {
+		fmt.Fprintf(buf, "%v\n", i)
+	}
+	return buf.String()
+}
+
+// Validate checks uploadables against the Limits of the client before a
+// transfer is created. Empty files are always rejected since they cannot be
+// uploaded. Duplicate names are rejected only if the client does not rename
+// them. CreateSession, Create and Boards.AddFiles call Validate and fail if
+// the report has errors.
+func (t *TransfersService) Validate(up ...Uploadable) *ValidationReport {
+	limits := t.client.Limits
+	if limits == nil {
+		limits = &Limits{}
+	}
+
+	r := &ValidationReport{Files: len(up)}
+	add := func(kind IssueKind, index int, name string, warning bool, format string, args ...interface{}) *Issue {
+		i := &Issue{
+			Kind:    kind,
+			Index:   index,
+			Name:    name,
+			Warning: warning,
+			Message: fmt.Sprintf(format, args...),
+		}
+		r.Issues = append(r.Issues, i)
+		return i
+	}
+
+	seen := make(map[string]bool, len(up))
+
+	for i, u := range up {
+		name, size := u.Stat()
+		r.TotalSize += size
+
+		if size <= 0 {
+			add(IssueEmptyFile, i, name, false, "file %q is empty", name)
+		}
+		if n := utf8.RuneCountInString(name); limits.MaxNameLength > 0 && n > limits.MaxNameLength {
+			add(IssueNameLength, i, name, false, "name of file %q is %d characters long, the maximum is %d", name, n, limits.MaxNameLength)
+		}
+		if s := sanitizeString(name); s != name {
+			add(IssueSanitizedName, i, name, true, "name of file %q will become %q", name, s)
+		}
+		if seen[name] {
+			issue := add(IssueDuplicateName, i, name, t.client.DuplicateNames == DuplicateRename, "more than one file is named %q", name)
+			issue.err = &DuplicateNameError{Name: name}
+		}
+		seen[name] = true
+	}
+
+	if limits.MaxFiles > 0 && len(up) > limits.MaxFiles {
+		add(IssueFileCount, -1, "", false, "transfer has %d files, the maximum is %d", len(up), limits.MaxFiles)
+	}
+	if limits.MaxSize > 0 && r.TotalSize > limits.MaxSize {
+		add(IssueTotalSize, -1, "", false, "transfer is %d bytes, the maximum is %d", r.TotalSize, limits.MaxSize)
+	}
+
+	return r
+}
+
+// prepareUploads validates uploadables and gives each a name of its own
+// according to the DuplicateNames policy of the client. Since a renamed file
+// may get a name too long, the uploadables are validated again once renamed.
+func (c *Client) prepareUploads(up []Uploadable) ([]Uploadable, error) {
+	if err := c.Transfers.Validate(up...).Err(); err != nil {
+		return nil, err
+	}
+
+	up, err := uniqueNames(c.DuplicateNames, up)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Transfers.Validate(up...).Err(); err != nil {
+		return nil, err
+	}
+	return up, nil
+}
@@ -0,0 +1,159 @@
+package wt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func issueKinds(issues []*Issue) []IssueKind {
+	var kinds []IssueKind
+	for _, i := range issues {
+		kinds = append(kinds, i.Kind)
+	}
+	return kinds
+}
+
+func TestTransfersService_Validate(t *testing.T) {
+	client, _ := NewClient("abc")
+
+	r := client.Transfers.Validate(
+		NewBuffer("a.txt", []byte("a")),
+		NewBuffer("b.txt", []byte("bb")),
+	)
+
+	if r.Files != 2 || r.TotalSize != 3 {
+		t.Errorf("Validate counted %v files and %v bytes, want 2 and 3", r.Files, r.TotalSize)
+	}
+	if len(r.Issues) != 0 || r.Err() != nil {
+		t.Errorf("Validate returned issues %v", r.Issues)
+	}
+}
+
+func TestTransfersService_Validate_issues(t *testing.T) {
+	client, _ := NewClient("abc", WithLimits(&Limits{MaxSize: 4, MaxFiles: 3, MaxNameLength: 8}))
+
+	r := client.Transfers.Validate(
+		NewBuffer("empty.txt", nil),
+		NewBuffer("a:b", []byte("ab")),
+		NewBuffer("a:b", []byte("ab")),
+		NewBuffer("long-name.txt", []byte("x")),
+	)
+
+	want := []IssueKind{
+		IssueEmptyFile, IssueNameLength,
+		IssueSanitizedName,
+		IssueSanitizedName, IssueDuplicateName,
+		IssueNameLength,
+		IssueFileCount, IssueTotalSize,
+	}
+	if got := issueKinds(r.Issues); !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate returned issues %v, want %v", got, want)
+	}
+
+	if got, want := issueKinds(r.Warnings()), []IssueKind{IssueSanitizedName, IssueSanitizedName, IssueDuplicateName}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Warnings returned %v, want %v", got, want)
+	}
+
+	if i := r.Issues[4]; i.Index != 2 || i.Name != "a:b" {
+		t.Errorf("duplicate issue is about %v %q, want 2 %q", i.Index, i.Name, "a:b")
+	}
+	if i := r.Issues[6]; i.Index != -1 {
+		t.Errorf("file count issue index is %v, want -1", i.Index)
+	}
+
+	var verr *ValidationError
+	if err := r.Err(); !errors.As(err, &verr) || !strings.Contains(err.Error(), "5 error(s)") {
+		t.Errorf("Err returned %v, want a *ValidationError with 5 errors", err)
+	}
+}
+
+func TestTransfersService_Validate_duplicateError(t *testing.T) {
+	client, _ := NewClient("abc", WithDuplicateNames(DuplicateError))
+
+	r := client.Transfers.Validate(
+		NewBuffer("a.txt", []byte("a")),
+		NewBuffer("a.txt", []byte("a")),
+	)
+	if got, want := issueKinds(r.Errors()), []IssueKind{IssueDuplicateName}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Errors returned %v, want %v", got, want)
+	}
+
+	var dupErr *DuplicateNameError
+	if err := r.Err(); !errors.As(err, &dupErr) || dupErr.Name != "a.txt" {
+		t.Errorf("Err returned %v, want a *DuplicateNameError", err)
+	}
+}
+
+func TestTransfersService_Validate_noLimits(t *testing.T) {
+	client, _ := NewClient("abc", WithLimits(nil))
+
+	r := client.Transfers.Validate(
+		NewBuffer(strings.Repeat("x", 300), []byte("a")),
+		NewBuffer("empty.txt", nil),
+	)
+	if got, want := issueKinds(r.Issues), []IssueKind{IssueEmptyFile}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate returned issues %v, want %v", got, want)
+	}
+}
+
+func TestTransfersService_CreateSession_invalid(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/transfers", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Transfer created despite validation errors")
+	})
+
+	_, err := client.Transfers.CreateSession(context.Background(), nil, NewBuffer("empty.txt", nil))
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Errorf("CreateSession returned %v, want *ValidationError", err)
+	}
+}
+
+func TestTransfersService_CreateSession_renamedTooLong(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+	client.Limits = &Limits{MaxNameLength: 7}
+
+	mux.HandleFunc("/transfers", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Transfer created despite validation errors")
+	})
+
+	_, err := client.Transfers.CreateSession(context.Background(), nil,
+		NewBuffer("abc.txt", []byte("a")),
+		NewBuffer("abc.txt", []byte("b")),
+	)
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("CreateSession returned %v, want *ValidationError", err)
+	}
+	if got, want := issueKinds(verr.Report.Errors()), []IssueKind{IssueNameLength}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CreateSession returned issues %v, want %v", got, want)
+	}
+	if got, want := verr.Report.Issues[0].Name, "abc (1).txt"; got != want {
+		t.Errorf("CreateSession returned an issue about %q, want %q", got, want)
+	}
+}
+
+func TestBoardsService_AddFiles_invalid(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/boards/1/files", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Files added despite validation errors")
+	})
+
+	_, err := client.Boards.AddFiles(context.Background(), &Board{ID: String("1")}, NewBuffer("empty.txt", nil))
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Errorf("AddFiles returned %v, want *ValidationError", err)
+	}
+}
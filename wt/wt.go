@@ -4,24 +4,32 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
 	defaultBaseURL = "https://dev.wetransfer.com/v2/"
 	userAgent      = "go-wt"
 	contentType    = "application/json"
+
+	// cleanupTimeout bounds the cleanup of an abandoned operation.
+	cleanupTimeout = 30 * time.Second
 )
 
 // A Client manages communication with the WeTransfer API.
 type Client struct {
-	client *http.Client // HTTP client used to communicate with the API.
+	client  *http.Client // HTTP client used to communicate with the API.
+	storage *http.Client // HTTP client used to communicate with the storage.
+
+	logger *slog.Logger // nil if logging is disabled
 
 	// Base URL for API requests. Defaults to the public WeTransfer API.
 	// Base URL should always be specified with a trailing slash.
@@ -30,12 +38,75 @@ type Client struct {
 	// WeTransfer API key
 	APIKey string
 
-	// WeTransfer JWT Authorization token
+	// WeTransfer JWT Authorization token. It is set by Authorize and
+	// refreshed by the client when it expires.
 	JWTAuthToken string
 
+	// TokenSource supplies the JWT tokens of the client. If nil, tokens are
+	// requested from the API with the API key.
+	TokenSource TokenSource
+
 	// User agent used when communicating with the API.
 	UserAgent string
 
+	// Concurrency is the maximum number of parts uploaded at the same time
+	// across all files. Each part being uploaded holds one chunk in memory,
+	// so memory use stays at roughly Concurrency times the chunk size. It
+	// must be set before the first upload.
+	Concurrency int
+
+	// FileConcurrency is the maximum number of parts of a single file
+	// uploaded at the same time.
+	FileConcurrency int
+
+	// RetryPolicy describes how requests failing with a transient error are
+	// retried. A nil policy disables retries.
+	RetryPolicy *RetryPolicy
+
+	// DuplicateNames tells what to do with uploadables sharing a name in a
+	// single transfer or board upload. They are renamed by default.
+	DuplicateNames DuplicatePolicy
+
+	// Limits are checked by Validate before files are uploaded. Nil
+	// disables the limits, but not the checks of file names and empty files.
+	Limits *Limits
+
+	// TitleResolver, if set, finds the titles of links added to a board
+	// without one.
+	TitleResolver TitleResolver
+
+	// Progress, if set, receives the progress of transfers and board file
+	// uploads.
+	Progress ProgressFunc
+
+	// Debug, if set, makes the logger of the client record the headers and
+	// bodies of API requests and responses too. Secrets are redacted.
+	Debug bool
+
+	// Tracer, if set, traces the calls of the client. See Tracer.
+	Tracer Tracer
+
+	// Metrics, if set, collects the metrics of the client. See Metrics.
+	Metrics Metrics
+
+	// Cleanup, if set, makes an upload abandoned because of an error or a
+	// canceled context remove what it created: Transfers.Create deletes
+	// the transfer, and Boards.AddFiles removes the items it added. Cleanup
+	// is best effort, its failures are only logged.
+	Cleanup bool
+
+	// State of the JWT token. Guarded by tokenMu.
+	tokenMu          sync.Mutex
+	tokenFor         string        // token tokenExpiry was decoded from
+	tokenExpiry      time.Time     // zero if unknown
+	refreshing       chan struct{} // closed once the refresh in flight is done
+	refreshErr       error         // error of the last refresh
+	refreshAbandoned bool          // the last refresh failed as its caller gave up
+
+	// Chunk buffers shared by all uploads. Lazily created from Concurrency.
+	pool     *bufferPool
+	poolOnce sync.Once
+
 	// Reuse a single struct instead of allocating one for each service on the heap.
 	common service
 
@@ -51,13 +122,10 @@ type service struct {
 	client *Client
 }
 
-// NewClient returns a new WeTransfer unauthorized API client. If a nil httpClient is
-// provided, http.DefaultClient will be used.
-func NewClient(apiKey string, httpClient *http.Client) (*Client, error) {
-	if httpClient == nil {
-		httpClient = http.DefaultClient
-	}
-
+// NewClient returns a new WeTransfer unauthorized API client configured with
+// the given options. Unless configured otherwise, http.DefaultClient is used to
+// communicate with the API and the storage.
+func NewClient(apiKey string, opts ...Option) (*Client, error) {
 	if apiKey == "" {
 		return nil, fmt.Errorf("APIKey must not be blank")
 	}
@@ -65,10 +133,27 @@ func NewClient(apiKey string, httpClient *http.Client) (*Client, error) {
 	baseURL, _ := url.Parse(defaultBaseURL)
 
 	c := &Client{
-		client:    httpClient,
-		BaseURL:   baseURL,
-		APIKey:    apiKey,
-		UserAgent: userAgent,
+		client:          http.DefaultClient,
+		BaseURL:         baseURL,
+		APIKey:          apiKey,
+		UserAgent:       userAgent,
+		Concurrency:     defaultConcurrency,
+		FileConcurrency: defaultFileConcurrency,
+		RetryPolicy:     DefaultRetryPolicy(),
+		Limits:          DefaultLimits(),
+	}
+
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.storage == nil {
+		c.storage = c.client
 	}
 
 	c.common.client = c
@@ -80,9 +165,41 @@ func NewClient(apiKey string, httpClient *http.Client) (*Client, error) {
 	return c, nil
 }
 
-// NewAuthorizedClient returns a new WeTransfer authorized API client.
-func NewAuthorizedClient(ctx context.Context, apiKey string, httpClient *http.Client) (*Client, error) {
-	client, err := NewClient(apiKey, nil)
+// log records a message with the logger of the client, if any.
+func (c *Client) log(ctx context.Context, level slog.Level, msg string, args ...interface{}) {
+	if c.logger != nil {
+		c.logger.Log(ctx, level, msg, args...)
+	}
+}
+
+// cleanup runs fn to undo part of an abandoned operation if Cleanup is set.
+// fn gets a context which is not canceled along with ctx, as ctx is often the
+// reason the operation was abandoned.
+func (c *Client) cleanup(ctx context.Context, what string, fn func(context.Context) error) {
+	if !c.Cleanup {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+	defer cancel()
+
+	if err := fn(ctx); err != nil {
+		c.log(ctx, slog.LevelWarn, "cleanup failed", "what", what, "error", err)
+	}
+}
+
+// chunkPool returns the chunk buffers shared by all uploads of the client.
+func (c *Client) chunkPool() *bufferPool {
+	c.poolOnce.Do(func() {
+		c.pool = newBufferPool(c.Concurrency)
+	})
+	return c.pool
+}
+
+// NewAuthorizedClient returns a new WeTransfer authorized API client configured
+// with the given options.
+func NewAuthorizedClient(ctx context.Context, apiKey string, opts ...Option) (*Client, error) {
+	client, err := NewClient(apiKey, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -131,8 +248,8 @@ func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Requ
 		req.Header.Set("User-Agent", c.UserAgent)
 	}
 
-	if c.JWTAuthToken != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", c.JWTAuthToken))
+	if tok := c.currentToken(); tok != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", tok))
 	}
 
 	req.Header.Set("x-api-key", c.APIKey)
@@ -146,11 +263,105 @@ func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Requ
 // interface, the raw response body will be written to v, without attempting to
 // first decode it.
 //
-// The provided ctx must be non-nil. If it is canceled or times out,
-// ctx.Err() will be returned.
+// Idempotent requests failing with a transient error are retried according to
+// the RetryPolicy of the client. The JWT token of an authorized client is
+// refreshed before it expires, and a request rejected with a 401 is replayed
+// once with a new token.
+//
+// The request is bound to the provided ctx, which must be non-nil. If it is
+// canceled or times out, ctx.Err() will be returned.
 func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*http.Response, error) {
+	tok, err := c.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	setBearer(req, tok)
 
-	resp, err := c.client.Do(req)
+	resp, err := c.send(ctx, req, v)
+	if tok == "" || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// The token was rejected, most likely because it expired.
+	if req.Body != nil && req.GetBody == nil {
+		return resp, err
+	}
+	if _, rerr := c.refreshToken(ctx, tok); rerr != nil {
+		return resp, err
+	}
+	if req.GetBody != nil {
+		if req.Body, err = req.GetBody(); err != nil {
+			return resp, err
+		}
+	}
+	setBearer(req, c.currentToken())
+
+	return c.send(ctx, req, v)
+}
+
+// setBearer sets the Authorization header of req to the JWT token, if any.
+func setBearer(req *http.Request, tok string) {
+	if tok != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", tok))
+	}
+}
+
+// send sends an API request, retrying it if needed. See Do.
+func (c *Client) send(ctx context.Context, req *http.Request, v interface{}) (*http.Response, error) {
+	req = req.WithContext(ctx)
+
+	policy := c.RetryPolicy
+	if !isIdempotent(req.Method) || (req.Body != nil && req.GetBody == nil) {
+		policy = nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.do(ctx, req, v, attempt)
+		if err == nil || !policy.shouldRetry(ctx, attempt, resp, err) {
+			return resp, err
+		}
+
+		if err := policy.wait(ctx, attempt, resp); err != nil {
+			return resp, err
+		}
+		c.retried("api_request")
+		c.log(ctx, slog.LevelDebug, "retrying request",
+			"method", req.Method, "path", req.URL.Path, "attempt", attempt+1, "error", err)
+
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return resp, err
+			}
+		}
+	}
+}
+
+// do sends a single attempt of an API request, and logs it. See Do.
+func (c *Client) do(ctx context.Context, req *http.Request, v interface{}, attempt int) (resp *http.Response, err error) {
+	ctx, span := c.startSpan(ctx, "wt.api.request",
+		attr("http.request.method", req.Method),
+		attr("url.path", req.URL.Path),
+		attr("wt.attempt", attempt))
+	req = req.WithContext(ctx)
+	c.inject(ctx, req)
+
+	start := time.Now()
+	defer func() {
+		c.logRequest(ctx, "api request", req, resp, start, attempt, err)
+
+		labels := []Label{{"method", req.Method}, {"endpoint", c.endpoint(req.URL)}, statusLabel(resp)}
+		c.count(MetricAPIRequests, 1, labels...)
+		c.observe(MetricAPIRequestDuration, seconds(start), labels...)
+
+		if resp != nil {
+			span.set(attr("http.response.status_code", resp.StatusCode))
+		}
+		span.end(err)
+	}()
+
+	c.dumpRequest(ctx, req)
+
+	resp, err = c.client.Do(req)
 	if err != nil {
 		// If we got an error, and the context has been canceled,
 		// the context's error is probably more useful.
@@ -160,10 +371,12 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*htt
 		default:
 		}
 
-		return nil, err
+		return nil, redactError(err)
 	}
 	defer resp.Body.Close()
 
+	c.dumpResponse(ctx, resp)
+
 	err = CheckResponse(resp)
 	if err != nil {
 		return resp, err
@@ -187,7 +400,9 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*htt
 }
 
 // CheckResponse checks the API response for errors, and returns them if
-// present.
+// present. An error is an *AuthError, a *NotFoundError or a *RateLimitError
+// when the status code calls for it, or an *ErrorResponse otherwise. All of
+// them unwrap to the *ErrorResponse.
 // WeTransfer API docs: https://developers.wetransfer.com/documentation
 func CheckResponse(r *http.Response) error {
 	if c := r.StatusCode; 200 <= c && c <= 299 {
@@ -200,13 +415,28 @@ func CheckResponse(r *http.Response) error {
 		return err
 	}
 
-	if data != nil {
-		err := json.Unmarshal(data, errResp)
-		if err != nil {
-			return err
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, errResp); err != nil {
+			// Not a JSON body, such as the HTML page of a proxy. Keep the
+			// body as the message so the status is not lost.
+			errResp.Message = truncate(strings.TrimSpace(string(data)), maxErrorBody)
 		}
 	}
 
+	if errResp.Message == "" {
+		errResp.Message = http.StatusText(r.StatusCode)
+	}
+
+	switch r.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return &AuthError{ErrorResponse: errResp}
+	case http.StatusNotFound:
+		return &NotFoundError{ErrorResponse: errResp}
+	case http.StatusTooManyRequests:
+		d, _ := retryAfter(r)
+		return &RateLimitError{ErrorResponse: errResp, RetryAfter: d}
+	}
+
 	return errResp
 }
 
@@ -218,8 +448,8 @@ type ErrorResponse struct {
 
 func (r *ErrorResponse) Error() string {
 	return fmt.Sprintf("%v %v: %d %v",
-		r.Response.Request.Method, r.Response.Request.URL,
-		r.Response.StatusCode, r.Message)
+		r.Response.Request.Method, redactURL(r.Response.Request.URL),
+		r.Response.StatusCode, redactBody([]byte(r.Message)))
 }
 
 // Bool is a helper routine that allocates a new bool value
@@ -237,14 +467,3 @@ func Int64(v int64) *int64 { return &v }
 // String is a helper routine that allocates a new string value
 // to store v and returns a pointer to it.
 func String(v string) *string { return &v }
-
-func joinErrors(err []error, m *string) error {
-	buf := new(bytes.Buffer)
-	if m != nil {
-		fmt.Fprintf(buf, "%v:\n", *m)
-	}
-	for _, e := range err {
-		fmt.Fprintf(buf, "%v\n", e.Error())
-	}
-	return errors.New(buf.String())
-}
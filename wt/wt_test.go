@@ -1,15 +1,17 @@
 package wt
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
-	"net/url"
 	"os"
 	"path"
+	"sync"
 	"testing"
+	"time"
 )
 
 const (
@@ -26,10 +28,7 @@ func setup() (client *Client, mux *http.ServeMux, serverURL string, teardown fun
 	server := httptest.NewServer(mux)
 
 	// client configured to use test server
-	client, _ = NewClient(testAPIKey, nil)
-	url, _ := url.Parse(server.URL + "/")
-
-	client.BaseURL = url
+	client, _ = NewClient(testAPIKey, WithBaseURL(server.URL))
 	client.JWTAuthToken = testJWTAuthToken
 
 	return client, mux, server.URL, server.Close
@@ -52,7 +51,8 @@ func testHeader(t *testing.T, r *http.Request, header string, want string) {
 // testErrorResponse checks the message of an ErrorResponse. If it matches that
 // given message string, then it passes.
 func testErrorResponse(t *testing.T, err error, message string) {
-	v, ok := err.(*ErrorResponse)
+	var v *ErrorResponse
+	ok := errors.As(err, &v)
 
 	if ok && v.Message != message {
 		t.Errorf("ErrorResponse.Message returned %v, want %+v", v.Message, message)
@@ -98,17 +98,124 @@ func TestNewClient(t *testing.T) {
 	}
 }
 
-func TestJoinErrors(t *testing.T) {
-	errs := []error{
-		errors.New("error 1"),
-		errors.New("error 2"),
+func TestNewClient_options(t *testing.T) {
+	api := &http.Client{}
+	storage := &http.Client{}
+	policy := &RetryPolicy{MaxAttempts: 2}
+	limits := &Limits{MaxFiles: 10}
+
+	c, err := NewClient("abc",
+		WithHTTPClient(api),
+		WithStorageHTTPClient(storage),
+		WithBaseURL("https://example.com/v2"),
+		WithUserAgent("pony"),
+		WithRetryPolicy(policy),
+		WithConcurrency(3, 2),
+		WithLimits(limits),
+		WithTitleResolver(HTMLTitleResolver),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("NewClient returned an error: %v", err)
 	}
 
-	err := joinErrors(errs, String("context"))
+	if c.client != api || c.storage != storage {
+		t.Errorf("NewClient did not use the given HTTP clients")
+	}
+	if got, want := c.BaseURL.String(), "https://example.com/v2/"; got != want {
+		t.Errorf("NewClient BaseURL is %v, want %v", got, want)
+	}
+	if got, want := c.UserAgent, "pony"; got != want {
+		t.Errorf("NewClient UserAgent is %v, want %v", got, want)
+	}
+	if c.RetryPolicy != policy {
+		t.Errorf("NewClient RetryPolicy is %v, want %v", c.RetryPolicy, policy)
+	}
+	if c.Concurrency != 3 || c.FileConcurrency != 2 {
+		t.Errorf("NewClient concurrency is %v/%v, want %v/%v", c.Concurrency, c.FileConcurrency, 3, 2)
+	}
+	if c.Limits != limits {
+		t.Errorf("NewClient Limits is %v, want %v", c.Limits, limits)
+	}
+	if c.TitleResolver == nil {
+		t.Errorf("NewClient TitleResolver is nil")
+	}
+}
+
+func TestNewClient_storageDefaultsToHTTPClient(t *testing.T) {
+	api := &http.Client{}
 
-	want := "context:\nerror 1\nerror 2\n"
+	c, _ := NewClient("abc", WithHTTPClient(api))
+	if c.storage != api {
+		t.Errorf("NewClient storage client is not the API HTTP client")
+	}
+}
+
+func TestNewClient_badOption(t *testing.T) {
+	if _, err := NewClient("abc", WithConcurrency(0, 1)); err == nil {
+		t.Errorf("Expected error to be returned")
+	}
+	if _, err := NewClient("abc", WithBaseURL("v2/")); err == nil {
+		t.Errorf("Expected error to be returned")
+	}
+}
 
-	if err.Error() != want {
-		t.Errorf("joinErrors returned %v, want %v", err.Error(), want)
+// countingTransport is an http.RoundTripper counting the requests it sends.
+type countingTransport struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (c *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.count++
+	c.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestClient_storageHTTPClient(t *testing.T) {
+	client, mux, srvURL, teardown := setup()
+	defer teardown()
+
+	transport := &countingTransport{}
+	client.storage = &http.Client{Transport: transport}
+
+	mux.HandleFunc("/transfers/1/files/1/upload-url/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"success": true, "url": "%v/part/1"}`, srvURL)
+	})
+	mux.HandleFunc("/part/1", func(w http.ResponseWriter, r *http.Request) {})
+
+	_, err := client.uploader.uploadPart(context.Background(), &Transfer{ID: String("1")}, "1", 1, "", []byte("yehaa"))
+	if err != nil {
+		t.Errorf("uploadPart returned an error: %v", err)
+	}
+
+	if transport.count != 1 {
+		t.Errorf("Storage HTTP client sent %v requests, want %v", transport.count, 1)
+	}
+}
+
+func TestClient_Do_contextDone(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	req, _ := client.NewRequest("GET", ".", nil)
+
+	start := time.Now()
+	if _, err := client.Do(ctx, req, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do returned %v, want %v", err, context.DeadlineExceeded)
+	}
+	if got := time.Since(start); got > 5*time.Second {
+		t.Errorf("Do took %v after the context was done", got)
 	}
 }
@@ -0,0 +1,934 @@
+// Package wttest provides an in-memory fake of the WeTransfer API, and of the
+// storage files are uploaded to, for testing code built on the wt package
+// without network access nor an API key.
+package wttest
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tors/wt-go-sdk/wt"
+)
+
+const (
+	apiPrefix     = "/v2/"
+	storagePrefix = "/storage/"
+
+	defaultChunkSize    = 5 * 1024 * 1024
+	defaultTokenTTL     = time.Hour
+	defaultUploadURLTTL = time.Hour
+	transferTTL         = 7 * 24 * time.Hour
+)
+
+// Server is a fake WeTransfer API. It keeps transfers and boards in memory and
+// follows the state transitions of the real API: files must be uploaded
+// before their upload is completed, and completed before a transfer is
+// finalized. Failures can be injected with Inject.
+type Server struct {
+	// APIKey is the only API key accepted by the server. If blank, any
+	// API key is accepted.
+	APIKey string
+
+	// ChunkSize is the size of the parts files are split into.
+	ChunkSize int64
+
+	// TokenTTL is the lifetime of the JWT tokens issued by the server.
+	TokenTTL time.Duration
+
+	// UploadURLTTL is the lifetime of presigned upload and download URLs.
+	UploadURLTTL time.Duration
+
+	srv *httptest.Server
+
+	mu        sync.Mutex
+	tokens    map[string]time.Time // JWT tokens and their expiry
+	transfers map[string]*transfer
+	boards    map[string]*board
+	urls      map[string]*presigned // storage keys
+	faults    []*Fault
+}
+
+type file struct {
+	id          string
+	name        string
+	size        int64
+	chunkSize   int64
+	parts       int64
+	multipartID string
+	data        map[int64][]byte
+	completed   bool
+}
+
+type transfer struct {
+	id        string
+	message   *string
+	state     string
+	expiresAt time.Time
+	files     []*file
+}
+
+type item struct {
+	id    string
+	typ   string
+	url   string
+	title *string
+	file  *file
+}
+
+type board struct {
+	id    string
+	name  string
+	desc  *string
+	items []*item
+}
+
+// presigned is an upload or download URL of the storage.
+type presigned struct {
+	file    *file
+	part    int64 // 0 for downloads
+	expires time.Time
+}
+
+// Fault describes a failure injected into the responses of the server.
+type Fault struct {
+	// Method and Path select the requests the fault applies to. Path is a
+	// path.Match pattern of the path relative to the API root, such as
+	// "transfers/*/finalize", or "storage/*" for the storage. Blank values
+	// match any request.
+	Method string
+	Path   string
+
+	// Latency delays the response.
+	Latency time.Duration
+
+	// Status, if set, is returned instead of the actual response.
+	Status int
+
+	// ExpireURL makes the upload and download URLs issued in response to
+	// the requests already expired.
+	ExpireURL bool
+
+	// Corrupt alters the content of the parts uploaded to the storage on
+	// their way, as a faulty network would.
+	Corrupt bool
+
+	// Times is the number of requests the fault applies to. Zero means all
+	// of them.
+	Times int
+}
+
+type (
+	expireURLKey struct{}
+	corruptKey   struct{}
+)
+
+// NewServer starts and returns a new fake WeTransfer API. The caller should
+// call Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		ChunkSize:    defaultChunkSize,
+		TokenTTL:     defaultTokenTTL,
+		UploadURLTTL: defaultUploadURLTTL,
+		tokens:       make(map[string]time.Time),
+		transfers:    make(map[string]*transfer),
+		boards:       make(map[string]*board),
+		urls:         make(map[string]*presigned),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// URL returns the base URL of the API, with a trailing slash.
+func (s *Server) URL() string {
+	return s.srv.URL + apiPrefix
+}
+
+// NewClient returns a client of the server authorized with its API key.
+func (s *Server) NewClient(ctx context.Context, opts ...wt.Option) (*wt.Client, error) {
+	apiKey := s.APIKey
+	if apiKey == "" {
+		apiKey = "wttest"
+	}
+	opts = append([]wt.Option{wt.WithBaseURL(s.URL())}, opts...)
+	return wt.NewAuthorizedClient(ctx, apiKey, opts...)
+}
+
+// Inject adds a fault to the responses of the server.
+func (s *Server) Inject(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all injected faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// ExpireURLs expires the presigned URLs issued so far, as if the client took
+// too long to use them.
+func (s *Server) ExpireURLs() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.urls {
+		u.expires = time.Now().Add(-time.Second)
+	}
+}
+
+// ExpireTokens expires the JWT tokens issued so far.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for tok := range s.tokens {
+		s.tokens[tok] = time.Now().Add(-time.Second)
+	}
+}
+
+// FileContent returns the uploaded content of a file of a transfer or a
+// board, and whether the file exists.
+func (s *Server) FileContent(id, fileID string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f := s.findFile(id, fileID)
+	if f == nil {
+		return nil, false
+	}
+	return f.content(), true
+}
+
+func (s *Server) findFile(id, fileID string) *file {
+	if t, ok := s.transfers[id]; ok {
+		for _, f := range t.files {
+			if f.id == fileID {
+				return f
+			}
+		}
+	}
+	if b, ok := s.boards[id]; ok {
+		for _, i := range b.items {
+			if i.file != nil && i.id == fileID {
+				return i.file
+			}
+		}
+	}
+	return nil
+}
+
+// content returns the parts of the file put together.
+func (f *file) content() []byte {
+	var data []byte
+	for n := int64(1); n <= f.parts; n++ {
+		data = append(data, f.data[n]...)
+	}
+	return data
+}
+
+// missing returns the part numbers of the file not uploaded yet.
+func (f *file) missing() []string {
+	var missing []string
+	for n := int64(1); n <= f.parts; n++ {
+		if _, ok := f.data[n]; !ok {
+			missing = append(missing, fmt.Sprint(n))
+		}
+	}
+	return missing
+}
+
+// fault returns the fault applying to a request, if any.
+func (s *Server) fault(method, rel string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, f := range s.faults {
+		if f.Method != "" && f.Method != method {
+			continue
+		}
+		if f.Path != "" {
+			if ok, _ := path.Match(f.Path, rel); !ok {
+				continue
+			}
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	var rel string
+	switch {
+	case strings.HasPrefix(r.URL.Path, apiPrefix):
+		rel = strings.TrimPrefix(r.URL.Path, apiPrefix)
+	case strings.HasPrefix(r.URL.Path, storagePrefix):
+		rel = strings.TrimPrefix(r.URL.Path, "/")
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if f := s.fault(r.Method, rel); f != nil {
+		if f.Latency > 0 {
+			// Until the body is read, the server does not notice clients
+			// giving up on the request.
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+			select {
+			case <-time.After(f.Latency):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if f.Status != 0 {
+			writeError(w, f.Status, http.StatusText(f.Status))
+			return
+		}
+		if f.ExpireURL {
+			r = r.WithContext(context.WithValue(r.Context(), expireURLKey{}, true))
+		}
+		if f.Corrupt {
+			r = r.WithContext(context.WithValue(r.Context(), corruptKey{}, true))
+		}
+	}
+
+	if strings.HasPrefix(rel, "storage/") {
+		s.serveStorage(w, r, strings.TrimPrefix(rel, "storage/"))
+		return
+	}
+
+	if rel == "authorize" && r.Method == "POST" {
+		s.authorize(w, r)
+		return
+	}
+
+	if !s.authorized(w, r) {
+		return
+	}
+
+	s.serveAPI(w, r, strings.Split(rel, "/"))
+}
+
+// serveAPI routes an authorized API request given the segments of its path.
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request, p []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	route := func(method string, pattern ...string) bool {
+		if r.Method != method || len(p) != len(pattern) {
+			return false
+		}
+		for i := range pattern {
+			if pattern[i] != "*" && pattern[i] != p[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch {
+	case route("POST", "transfers"):
+		s.createTransfer(w, r)
+	case route("GET", "transfers", "*"):
+		s.findTransfer(w, p[1])
+	case route("DELETE", "transfers", "*"):
+		s.deleteTransfer(w, p[1])
+	case route("GET", "transfers", "*", "files", "*", "upload-url", "*"):
+		s.uploadURL(w, r, p[1], p[3], p[5], "")
+	case route("PUT", "transfers", "*", "files", "*", "upload-complete"):
+		s.completeTransferFile(w, r, p[1], p[3])
+	case route("PUT", "transfers", "*", "finalize"):
+		s.finalizeTransfer(w, p[1])
+	case route("GET", "transfers", "*", "files", "*", "download-url"):
+		s.downloadURL(w, r, p[1], p[3])
+	case route("POST", "boards"):
+		s.createBoard(w, r)
+	case route("GET", "boards", "*"):
+		s.findBoard(w, p[1])
+	case route("PATCH", "boards", "*"):
+		s.updateBoard(w, r, p[1])
+	case route("DELETE", "boards", "*"):
+		s.deleteBoard(w, p[1])
+	case route("DELETE", "boards", "*", "items", "*"):
+		s.removeItem(w, p[1], p[3])
+	case route("POST", "boards", "*", "links"):
+		s.addLinks(w, r, p[1])
+	case route("POST", "boards", "*", "files"):
+		s.addFiles(w, r, p[1])
+	case route("GET", "boards", "*", "files", "*", "upload-url", "*", "*"):
+		s.uploadURL(w, r, p[1], p[3], p[5], p[6])
+	case route("PUT", "boards", "*", "files", "*", "upload-complete"):
+		s.completeBoardFile(w, p[1], p[3])
+	default:
+		writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("x-api-key")
+	if key == "" || (s.APIKey != "" && key != s.APIKey) {
+		writeError(w, http.StatusForbidden, "Forbidden: invalid API Key")
+		return
+	}
+
+	exp := time.Now().Add(s.TokenTTL)
+	enc := base64.RawURLEncoding
+	tok := enc.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." +
+		enc.EncodeToString([]byte(fmt.Sprintf(`{"sub":%q,"exp":%d}`, newID(), exp.Unix()))) + "."
+
+	s.mu.Lock()
+	s.tokens[tok] = exp
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "token": tok})
+}
+
+// authorized checks the API key and the JWT token of a request, and replies
+// with an error if they are not valid.
+func (s *Server) authorized(w http.ResponseWriter, r *http.Request) bool {
+	key := r.Header.Get("x-api-key")
+	if key == "" || (s.APIKey != "" && key != s.APIKey) {
+		writeError(w, http.StatusForbidden, "Forbidden: invalid API Key")
+		return false
+	}
+
+	tok := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	s.mu.Lock()
+	exp, ok := s.tokens[tok]
+	s.mu.Unlock()
+
+	if !ok || time.Now().After(exp) {
+		writeError(w, http.StatusUnauthorized, "Unauthorized: invalid or expired token")
+		return false
+	}
+	return true
+}
+
+type fileObject struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// newFiles validates file objects sent by a client and returns files ready to
+// be uploaded.
+func (s *Server) newFiles(objs []fileObject, multipart bool) ([]*file, error) {
+	if len(objs) == 0 {
+		return nil, fmt.Errorf("files must contain at least 1 item")
+	}
+
+	var files []*file
+	for i, o := range objs {
+		if o.Name == "" {
+			return nil, fmt.Errorf("files[%d].name is not allowed to be empty", i)
+		}
+		if o.Size <= 0 {
+			return nil, fmt.Errorf("files[%d].size must be larger than or equal to 1", i)
+		}
+		f := &file{
+			id:        newID(),
+			name:      o.Name,
+			size:      o.Size,
+			chunkSize: s.ChunkSize,
+			parts:     (o.Size + s.ChunkSize - 1) / s.ChunkSize,
+			data:      make(map[int64][]byte),
+		}
+		if f.parts == 1 {
+			f.chunkSize = o.Size
+		}
+		if multipart {
+			f.multipartID = newID()
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+func (s *Server) createTransfer(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Message *string      `json:"message"`
+		Files   []fileObject `json:"files"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Bad request")
+		return
+	}
+
+	files, err := s.newFiles(body.Files, false)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	t := &transfer{
+		id:        newID(),
+		message:   body.Message,
+		state:     "uploading",
+		expiresAt: time.Now().Add(transferTTL),
+		files:     files,
+	}
+	s.transfers[t.id] = t
+
+	writeJSON(w, http.StatusCreated, t.toAPI())
+}
+
+func (s *Server) findTransfer(w http.ResponseWriter, id string) {
+	t, ok := s.transfers[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Couldn't find Transfer. See https://developers.wetransfer.com/documentation")
+		return
+	}
+	writeJSON(w, http.StatusOK, t.toAPI())
+}
+
+func (s *Server) deleteTransfer(w http.ResponseWriter, id string) {
+	if _, ok := s.transfers[id]; !ok {
+		writeError(w, http.StatusNotFound, "Couldn't find Transfer. See https://developers.wetransfer.com/documentation")
+		return
+	}
+	delete(s.transfers, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) uploadURL(w http.ResponseWriter, r *http.Request, id, fileID, part, multipartID string) {
+	f := s.findFile(id, fileID)
+	if f == nil || (multipartID != "" && multipartID != f.multipartID) {
+		writeError(w, http.StatusNotFound, "Invalid transfer or file id.")
+		return
+	}
+	if t, ok := s.transfers[id]; ok && t.state != "uploading" {
+		writeError(w, http.StatusConflict, "Transfer is not accepting uploads.")
+		return
+	}
+
+	var n int64
+	if _, err := fmt.Sscan(part, &n); err != nil || n < 1 {
+		writeError(w, http.StatusExpectationFailed, "Chunk numbers are 1-based")
+		return
+	}
+	if n > f.parts {
+		writeError(w, http.StatusExpectationFailed, fmt.Sprintf("Chunk number %d is out of range", n))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"url":     s.presign(r, f, n),
+	})
+}
+
+func (s *Server) downloadURL(w http.ResponseWriter, r *http.Request, id, fileID string) {
+	t, ok := s.transfers[id]
+	f := s.findFile(id, fileID)
+	if !ok || f == nil {
+		writeError(w, http.StatusNotFound, "Invalid transfer or file id.")
+		return
+	}
+	if t.state != "downloadable" {
+		writeError(w, http.StatusConflict, "Transfer is not downloadable yet.")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"url":     s.presign(r, f, 0),
+	})
+}
+
+// presign issues a storage URL for a part of a file, or for the whole file if
+// part is 0. Its query string mimics the signature of S3 presigned URLs.
+func (s *Server) presign(r *http.Request, f *file, part int64) string {
+	key := newID()
+	exp := time.Now().Add(s.UploadURLTTL)
+	if r.Context().Value(expireURLKey{}) != nil {
+		exp = time.Now().Add(-time.Second)
+	}
+	s.urls[key] = &presigned{file: f, part: part, expires: exp}
+	return fmt.Sprintf("%v%v%v?X-Amz-Expires=%d&X-Amz-Signature=%v",
+		s.srv.URL, storagePrefix, key, int(s.UploadURLTTL.Seconds()), newID())
+}
+
+func (s *Server) completeTransferFile(w http.ResponseWriter, r *http.Request, id, fileID string) {
+	f := s.findFile(id, fileID)
+	if _, ok := s.transfers[id]; !ok || f == nil {
+		writeError(w, http.StatusNotFound, "Invalid transfer or file id.")
+		return
+	}
+
+	var body struct {
+		PartNumbers int64 `json:"part_numbers"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	if body.PartNumbers != f.parts {
+		writeError(w, http.StatusExpectationFailed, fmt.Sprintf("Expected %d parts, got %d.", f.parts, body.PartNumbers))
+		return
+	}
+
+	if !s.complete(w, f) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":         f.id,
+		"retries":    0,
+		"name":       f.name,
+		"size":       f.size,
+		"chunk_size": f.chunkSize,
+	})
+}
+
+// complete marks the upload of a file as complete, or replies with an error
+// if some of its parts are missing.
+func (s *Server) complete(w http.ResponseWriter, f *file) bool {
+	if missing := f.missing(); len(missing) > 0 {
+		writeError(w, http.StatusExpectationFailed, fmt.Sprintf("Chunks %v are still missing.", strings.Join(missing, ", ")))
+		return false
+	}
+	if size := int64(len(f.content())); size != f.size {
+		writeError(w, http.StatusExpectationFailed, fmt.Sprintf("Expected %d bytes, got %d.", f.size, size))
+		return false
+	}
+	f.completed = true
+	return true
+}
+
+func (s *Server) finalizeTransfer(w http.ResponseWriter, id string) {
+	t, ok := s.transfers[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Couldn't find Transfer. See https://developers.wetransfer.com/documentation")
+		return
+	}
+
+	for _, f := range t.files {
+		if !f.completed {
+			writeError(w, http.StatusExpectationFailed, fmt.Sprintf("File %v has not been completed.", f.id))
+			return
+		}
+	}
+
+	t.state = "downloadable"
+	writeJSON(w, http.StatusOK, t.toAPI())
+}
+
+func (s *Server) createBoard(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name string  `json:"name"`
+		Desc *string `json:"description"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" {
+		writeError(w, http.StatusBadRequest, "\"name\" is required")
+		return
+	}
+
+	b := &board{
+		id:   newID(),
+		name: body.Name,
+		desc: body.Desc,
+	}
+	s.boards[b.id] = b
+
+	writeJSON(w, http.StatusCreated, b.toAPI())
+}
+
+func (s *Server) findBoard(w http.ResponseWriter, id string) {
+	b, ok := s.boards[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Couldn't find Board. See https://developers.wetransfer.com/documentation")
+		return
+	}
+	writeJSON(w, http.StatusOK, b.toAPI())
+}
+
+func (s *Server) updateBoard(w http.ResponseWriter, r *http.Request, id string) {
+	b, ok := s.boards[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Couldn't find Board. See https://developers.wetransfer.com/documentation")
+		return
+	}
+
+	var body struct {
+		Name *string `json:"name"`
+		Desc *string `json:"description"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || (body.Name != nil && *body.Name == "") {
+		writeError(w, http.StatusBadRequest, "\"name\" is not allowed to be empty")
+		return
+	}
+
+	if body.Name != nil {
+		b.name = *body.Name
+	}
+	if body.Desc != nil {
+		b.desc = body.Desc
+	}
+
+	writeJSON(w, http.StatusOK, b.toAPI())
+}
+
+func (s *Server) deleteBoard(w http.ResponseWriter, id string) {
+	if _, ok := s.boards[id]; !ok {
+		writeError(w, http.StatusNotFound, "Couldn't find Board. See https://developers.wetransfer.com/documentation")
+		return
+	}
+	delete(s.boards, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) removeItem(w http.ResponseWriter, id, itemID string) {
+	b, ok := s.boards[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Couldn't find Board. See https://developers.wetransfer.com/documentation")
+		return
+	}
+
+	for n, i := range b.items {
+		if i.id == itemID {
+			b.items = append(b.items[:n], b.items[n+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Couldn't find Item.")
+}
+
+func (s *Server) addLinks(w http.ResponseWriter, r *http.Request, id string) {
+	b, ok := s.boards[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Couldn't find Board. See https://developers.wetransfer.com/documentation")
+		return
+	}
+
+	var links []struct {
+		URL   string  `json:"url"`
+		Title *string `json:"title"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&links); err != nil || len(links) == 0 {
+		writeError(w, http.StatusBadRequest, "\"board.links\" must be an array.")
+		return
+	}
+
+	var items []*wt.Item
+	for _, l := range links {
+		if l.URL == "" {
+			writeError(w, http.StatusBadRequest, "\"url\" is required")
+			return
+		}
+		i := &item{id: newID(), typ: "link", url: l.URL, title: l.Title}
+		b.items = append(b.items, i)
+		items = append(items, i.toAPI())
+	}
+
+	writeJSON(w, http.StatusCreated, items)
+}
+
+func (s *Server) addFiles(w http.ResponseWriter, r *http.Request, id string) {
+	b, ok := s.boards[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Couldn't find Board. See https://developers.wetransfer.com/documentation")
+		return
+	}
+
+	var objs []fileObject
+	if err := json.NewDecoder(r.Body).Decode(&objs); err != nil {
+		writeError(w, http.StatusBadRequest, "\"board.files\" must be an array.")
+		return
+	}
+
+	files, err := s.newFiles(objs, true)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var items []*wt.Item
+	for _, f := range files {
+		i := &item{id: f.id, typ: "file", file: f}
+		b.items = append(b.items, i)
+		items = append(items, i.toAPI())
+	}
+
+	writeJSON(w, http.StatusCreated, items)
+}
+
+func (s *Server) completeBoardFile(w http.ResponseWriter, id, fileID string) {
+	f := s.findFile(id, fileID)
+	if _, ok := s.boards[id]; !ok || f == nil {
+		writeError(w, http.StatusNotFound, "Invalid board or file id.")
+		return
+	}
+
+	if !s.complete(w, f) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "File is marked as complete.",
+	})
+}
+
+// serveStorage handles uploads and downloads of presigned URLs.
+func (s *Server) serveStorage(w http.ResponseWriter, r *http.Request, key string) {
+	s.mu.Lock()
+	u, ok := s.urls[key]
+	var expires time.Time
+	if ok {
+		expires = u.expires
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeStorageError(w, http.StatusNotFound, "NoSuchKey", "The resource you requested does not exist")
+		return
+	}
+	if time.Now().After(expires) {
+		writeStorageError(w, http.StatusForbidden, "AccessDenied", "Request has expired")
+		return
+	}
+
+	switch {
+	case r.Method == "PUT" && u.part > 0:
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeStorageError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+			return
+		}
+		if r.Context().Value(corruptKey{}) != nil && len(data) > 0 {
+			data[0] ^= 0xff
+		}
+		sum := md5.Sum(data)
+		if want := r.Header.Get("Content-MD5"); want != "" && want != base64.StdEncoding.EncodeToString(sum[:]) {
+			writeStorageError(w, http.StatusBadRequest, "BadDigest", "The Content-MD5 you specified did not match what we received.")
+			return
+		}
+		s.mu.Lock()
+		u.file.data[u.part] = data
+		s.mu.Unlock()
+		w.Header().Set("ETag", fmt.Sprintf("%q", hex.EncodeToString(sum[:])))
+		w.WriteHeader(http.StatusOK)
+	case r.Method == "GET" && u.part == 0:
+		s.mu.Lock()
+		content := u.file.content()
+		s.mu.Unlock()
+		http.ServeContent(w, r, u.file.name, time.Time{}, strings.NewReader(string(content)))
+	default:
+		writeStorageError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed")
+	}
+}
+
+func (t *transfer) toAPI() *wt.Transfer {
+	tx := &wt.Transfer{
+		Success:   wt.Bool(true),
+		ID:        wt.String(t.id),
+		Message:   t.message,
+		State:     wt.String(t.state),
+		ExpiresAt: wt.String(t.expiresAt.UTC().Format(time.RFC3339)),
+		Files:     []*wt.File{},
+	}
+	if t.state == "downloadable" {
+		tx.URL = wt.String("https://we.tl/t-" + t.id)
+	}
+	for _, f := range t.files {
+		tx.Files = append(tx.Files, &wt.File{
+			Multipart: &wt.Multipart{
+				PartNumbers: wt.Int64(f.parts),
+				ChunkSize:   wt.Int64(f.chunkSize),
+			},
+			Size: wt.Int64(f.size),
+			Type: wt.String("file"),
+			Name: wt.String(f.name),
+			ID:   wt.String(f.id),
+		})
+	}
+	return tx
+}
+
+func (b *board) toAPI() *wt.Board {
+	bd := &wt.Board{
+		ID:    wt.String(b.id),
+		Name:  wt.String(b.name),
+		Desc:  b.desc,
+		State: wt.String("downloadable"),
+		URL:   wt.String("https://we.tl/b-" + b.id),
+		Items: []*wt.Item{},
+	}
+	for _, i := range b.items {
+		bd.Items = append(bd.Items, i.toAPI())
+	}
+	return bd
+}
+
+func (i *item) toAPI() *wt.Item {
+	it := &wt.Item{
+		ID:   wt.String(i.id),
+		Type: wt.String(i.typ),
+	}
+	if i.file != nil {
+		it.Name = wt.String(i.file.name)
+		it.Size = wt.Int64(i.file.size)
+		it.Multipart = &wt.Multipart{
+			ID:          wt.String(i.file.multipartID),
+			PartNumbers: wt.Int64(i.file.parts),
+			ChunkSize:   wt.Int64(i.file.chunkSize),
+		}
+	} else {
+		it.URL = wt.String(i.url)
+		title := i.url
+		if i.title != nil {
+			title = *i.title
+		}
+		it.Meta = &wt.Meta{Title: wt.String(title)}
+	}
+	return it
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]interface{}{"success": false, "message": message})
+}
+
+func writeStorageError(w http.ResponseWriter, code int, errCode, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(code)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>%v</Code><Message>%v</Message></Error>`, errCode, message)
+}
+
+// newID returns a random hexadecimal identifier.
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,406 @@
+package wttest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/tors/wt-go-sdk/wt"
+)
+
+func setup(t *testing.T) (*Server, *wt.Client) {
+	t.Helper()
+
+	s := NewServer()
+	s.ChunkSize = 4
+
+	policy := wt.DefaultRetryPolicy()
+	policy.MinBackoff = time.Millisecond
+	policy.MaxBackoff = time.Millisecond
+
+	client, err := s.NewClient(context.Background(), wt.WithRetryPolicy(policy))
+	if err != nil {
+		s.Close()
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	return s, client
+}
+
+func TestServer_transfer(t *testing.T) {
+	s, client := setup(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	content := []byte("hello, fake world")
+
+	transfer, err := client.Transfers.Create(ctx, wt.String("hi"), wt.NewBuffer("hello.txt", content))
+	if err != nil {
+		t.Fatalf("Transfers.Create returned error: %v", err)
+	}
+
+	if got, want := *transfer.State, "downloadable"; got != want {
+		t.Errorf("Transfers.Create state is %v, want %v", got, want)
+	}
+
+	f := transfer.Files[0]
+	if got, want := f.GetMultipart().GetPartNumbers(), int64(5); got != want {
+		t.Errorf("Transfers.Create part numbers is %v, want %v", got, want)
+	}
+
+	got, ok := s.FileContent(transfer.GetID(), f.GetID())
+	if !ok || !bytes.Equal(got, content) {
+		t.Errorf("FileContent returned %q, %v, want %q, true", got, ok, content)
+	}
+
+	found, err := client.Transfers.Find(ctx, transfer.GetID())
+	if err != nil {
+		t.Fatalf("Transfers.Find returned error: %v", err)
+	}
+	if found.GetID() != transfer.GetID() {
+		t.Errorf("Transfers.Find returned %v, want %v", found.GetID(), transfer.GetID())
+	}
+
+	var buf bytes.Buffer
+	if err := client.Transfers.Download(ctx, transfer, f.GetID(), &buf); err != nil {
+		t.Fatalf("Transfers.Download returned error: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), content) {
+		t.Errorf("Transfers.Download wrote %q, want %q", buf.Bytes(), content)
+	}
+}
+
+func TestServer_board(t *testing.T) {
+	s, client := setup(t)
+	defer s.Close()
+
+	ctx := context.Background()
+
+	board, err := client.Boards.Create(ctx, "board", nil)
+	if err != nil {
+		t.Fatalf("Boards.Create returned error: %v", err)
+	}
+
+	link, _ := wt.NewLink("https://example.com", wt.String("Example"))
+	if _, err := client.Boards.AddLinks(ctx, board, link); err != nil {
+		t.Fatalf("Boards.AddLinks returned error: %v", err)
+	}
+
+	items, err := client.Boards.AddFiles(ctx, board, wt.NewBuffer("a.txt", []byte("0123456789")))
+	if err != nil {
+		t.Fatalf("Boards.AddFiles returned error: %v", err)
+	}
+
+	got, _ := s.FileContent(board.GetID(), items[0].GetID())
+	if want := "0123456789"; string(got) != want {
+		t.Errorf("FileContent returned %q, want %q", got, want)
+	}
+
+	found, err := client.Boards.Find(ctx, board.GetID())
+	if err != nil {
+		t.Fatalf("Boards.Find returned error: %v", err)
+	}
+	if got, want := len(found.Items), 2; got != want {
+		t.Errorf("Boards.Find returned %v items, want %v", got, want)
+	}
+}
+
+func TestServer_boardLifecycle(t *testing.T) {
+	s, client := setup(t)
+	defer s.Close()
+
+	ctx := context.Background()
+
+	board, _ := client.Boards.Create(ctx, "board", nil)
+	link, _ := wt.NewLink("https://example.com", nil)
+	items, _ := client.Boards.AddLinks(ctx, board, link, link)
+
+	if err := client.Boards.RemoveItems(ctx, board, items[0].GetID()); err != nil {
+		t.Fatalf("Boards.RemoveItems returned error: %v", err)
+	}
+
+	board, err := client.Boards.Update(ctx, board, wt.String("renamed"), wt.String("desc"))
+	if err != nil {
+		t.Fatalf("Boards.Update returned error: %v", err)
+	}
+	if board.GetName() != "renamed" || board.GetDesc() != "desc" || len(board.Items) != 1 {
+		t.Errorf("Boards.Update returned %v", board)
+	}
+
+	if err := client.Boards.Delete(ctx, board); err != nil {
+		t.Fatalf("Boards.Delete returned error: %v", err)
+	}
+
+	var nf *wt.NotFoundError
+	if _, err := client.Boards.Find(ctx, board.GetID()); !errors.As(err, &nf) {
+		t.Errorf("Boards.Find returned %v, want *wt.NotFoundError", err)
+	}
+}
+
+func TestServer_notFound(t *testing.T) {
+	s, client := setup(t)
+	defer s.Close()
+
+	_, err := client.Transfers.Find(context.Background(), "nope")
+
+	var nf *wt.NotFoundError
+	if !errors.As(err, &nf) {
+		t.Errorf("Transfers.Find returned %v, want *wt.NotFoundError", err)
+	}
+}
+
+func TestServer_invalidAPIKey(t *testing.T) {
+	s := NewServer()
+	s.APIKey = "secret"
+	defer s.Close()
+
+	_, err := wt.NewAuthorizedClient(context.Background(), "wrong", wt.WithBaseURL(s.URL()))
+
+	var ae *wt.AuthError
+	if !errors.As(err, &ae) {
+		t.Errorf("NewAuthorizedClient returned %v, want *wt.AuthError", err)
+	}
+}
+
+func TestServer_finalizeIncomplete(t *testing.T) {
+	s, client := setup(t)
+	defer s.Close()
+
+	ctx := context.Background()
+
+	session, err := client.Transfers.CreateSession(ctx, nil, wt.NewBuffer("a.txt", []byte("abc")))
+	if err != nil {
+		t.Fatalf("Transfers.CreateSession returned error: %v", err)
+	}
+
+	req, _ := client.NewRequest("PUT", "transfers/"+session.Transfer.GetID()+"/finalize", nil)
+	resp, err := client.Do(ctx, req, nil)
+	if err == nil {
+		t.Fatal("Expected error to be returned")
+	}
+	if got, want := resp.StatusCode, http.StatusExpectationFailed; got != want {
+		t.Errorf("finalize returned status %v, want %v", got, want)
+	}
+}
+
+func TestServer_tokenExpiry(t *testing.T) {
+	s, client := setup(t)
+	defer s.Close()
+
+	s.ExpireTokens()
+
+	// The client replays the request with a new token.
+	if _, err := client.Boards.Create(context.Background(), "board", nil); err != nil {
+		t.Errorf("Boards.Create returned error: %v", err)
+	}
+}
+
+func TestServer_faultStatus(t *testing.T) {
+	s, client := setup(t)
+	defer s.Close()
+
+	ctx := context.Background()
+
+	// Parts and idempotent requests are retried.
+	s.Inject(Fault{Path: "storage/*", Status: http.StatusServiceUnavailable, Times: 2})
+	s.Inject(Fault{Method: "PUT", Path: "transfers/*/finalize", Status: http.StatusBadGateway, Times: 1})
+
+	transfer, err := client.Transfers.Create(ctx, nil, wt.NewBuffer("a.txt", []byte("0123456789")))
+	if err != nil {
+		t.Fatalf("Transfers.Create returned error: %v", err)
+	}
+	if got, want := *transfer.State, "downloadable"; got != want {
+		t.Errorf("Transfers.Create state is %v, want %v", got, want)
+	}
+
+	// Faults are used up.
+	if _, err := client.Transfers.Find(ctx, transfer.GetID()); err != nil {
+		t.Errorf("Transfers.Find returned error: %v", err)
+	}
+}
+
+func TestServer_faultPersistent(t *testing.T) {
+	s, client := setup(t)
+	defer s.Close()
+
+	s.Inject(Fault{Method: "POST", Path: "boards", Status: http.StatusInternalServerError})
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := client.Boards.Create(ctx, "board", nil); err == nil {
+			t.Fatal("Expected error to be returned")
+		}
+	}
+
+	s.ClearFaults()
+	if _, err := client.Boards.Create(ctx, "board", nil); err != nil {
+		t.Errorf("Boards.Create returned error: %v", err)
+	}
+}
+
+func TestServer_faultLatency(t *testing.T) {
+	s, client := setup(t)
+	defer s.Close()
+
+	latency := 50 * time.Millisecond
+	s.Inject(Fault{Path: "boards", Latency: latency})
+
+	start := time.Now()
+	if _, err := client.Boards.Create(context.Background(), "board", nil); err != nil {
+		t.Fatalf("Boards.Create returned error: %v", err)
+	}
+	if got := time.Since(start); got < latency {
+		t.Errorf("Boards.Create took %v, want at least %v", got, latency)
+	}
+
+	// The request is abandoned once the context is done.
+	s.Inject(Fault{Path: "boards", Latency: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), latency)
+	defer cancel()
+
+	start = time.Now()
+	if _, err := client.Boards.Create(ctx, "board", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Boards.Create returned %v, want %v", err, context.DeadlineExceeded)
+	}
+	if got := time.Since(start); got > 10*time.Second {
+		t.Errorf("Boards.Create took %v after the context was done", got)
+	}
+}
+
+func TestServer_cleanup(t *testing.T) {
+	s, client := setup(t)
+	defer s.Close()
+
+	client.Cleanup = true
+
+	// Parts hang until the upload is abandoned.
+	s.Inject(Fault{Path: "storage/*", Latency: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.Transfers.Create(ctx, nil, wt.NewBuffer("a.txt", []byte("content")))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Transfers.Create returned %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	s.mu.Lock()
+	n := len(s.transfers)
+	s.mu.Unlock()
+	if n != 0 {
+		t.Errorf("server has %v transfers left, want 0", n)
+	}
+}
+
+func TestServer_faultCorrupt(t *testing.T) {
+	s, client := setup(t)
+	defer s.Close()
+
+	content := []byte("0123456789")
+
+	// The storage rejects the corrupted part, which is uploaded again.
+	s.Inject(Fault{Path: "storage/*", Corrupt: true, Times: 1})
+
+	transfer, err := client.Transfers.Create(context.Background(), nil, wt.NewBuffer("a.txt", content))
+	if err != nil {
+		t.Fatalf("Transfers.Create returned error: %v", err)
+	}
+
+	f := transfer.Files[0]
+	got, _ := s.FileContent(transfer.GetID(), f.GetID())
+	if !bytes.Equal(got, content) {
+		t.Errorf("FileContent returned %q, want %q", got, content)
+	}
+
+	sum := sha256.Sum256(content)
+	if f.Digest == nil || f.Digest.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("Transfers.Create returned digest %+v, want SHA-256 %x", f.Digest, sum)
+	}
+	for _, p := range f.Digest.Parts {
+		if p == nil || p.ETag != fmt.Sprintf("%q", p.MD5) {
+			t.Errorf("Transfers.Create returned part digest %+v, want the ETag of its MD5", p)
+		}
+	}
+}
+
+func TestServer_expiredURLs(t *testing.T) {
+	s, client := setup(t)
+	defer s.Close()
+
+	ctx := context.Background()
+	content := []byte("0123456789")
+
+	// The first URL of each part has expired by the time it is used. The
+	// client fetches a new one.
+	s.Inject(Fault{Path: "transfers/*/files/*/upload-url/*", ExpireURL: true, Times: 3})
+
+	transfer, err := client.Transfers.Create(ctx, nil, wt.NewBuffer("a.txt", content))
+	if err != nil {
+		t.Fatalf("Transfers.Create returned error: %v", err)
+	}
+
+	got, _ := s.FileContent(transfer.GetID(), transfer.Files[0].GetID())
+	if !bytes.Equal(got, content) {
+		t.Errorf("FileContent returned %q, want %q", got, content)
+	}
+}
+
+func TestServer_ExpireURLs(t *testing.T) {
+	s, client := setup(t)
+	defer s.Close()
+
+	ctx := context.Background()
+
+	transfer, err := client.Transfers.Create(ctx, nil, wt.NewBuffer("a.txt", []byte("abc")))
+	if err != nil {
+		t.Fatalf("Transfers.Create returned error: %v", err)
+	}
+
+	req, _ := client.NewRequest("GET", "transfers/"+transfer.GetID()+"/files/"+transfer.Files[0].GetID()+"/download-url", nil)
+	durl := new(wt.DownloadURL)
+	if _, err := client.Do(ctx, req, durl); err != nil {
+		t.Fatalf("download-url returned error: %v", err)
+	}
+
+	s.ExpireURLs()
+
+	resp, err := http.Get(durl.GetURL())
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	resp.Body.Close()
+	if got, want := resp.StatusCode, http.StatusForbidden; got != want {
+		t.Errorf("Get returned status %v, want %v", got, want)
+	}
+}
+
+func TestServer_ExpireURLs_concurrent(t *testing.T) {
+	s, client := setup(t)
+	defer s.Close()
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				s.ExpireURLs()
+			}
+		}
+	}()
+
+	// URLs expire while parts are uploaded, the upload may fail but must
+	// not race.
+	client.Transfers.Create(context.Background(), nil, wt.NewBuffer("a.txt", []byte("0123456789abcdef")))
+	close(done)
+	<-stopped
+}